@@ -0,0 +1,68 @@
+// Copyright © 2019 Codefresh.Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/codefresh-io/go-sdk/internal"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"github.com/codefresh-io/go-sdk/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var applyFile string
+
+// applyCmd creates or updates the pipeline described in a file
+var applyCmd = &cobra.Command{
+	Use:     "apply",
+	Example: "cfctl apply -f pipeline.yaml",
+	Short:   "Create or update a pipeline from a file",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := viper.Get("codefresh")
+		codefreshClient := utils.CastToCodefreshOrDie(client)
+		desired, err := readPipelineFile(applyFile)
+		internal.DieOnError(err)
+		res, err := codefreshClient.Pipelines().ApplyWithContext(context.Background(), desired)
+		internal.DieOnError(err)
+		if len(res.Diff) > 0 {
+			fmt.Println(res.Diff)
+		}
+		fmt.Printf("Pipeline %s %s\n", desired.Metadata.Name, res.Action)
+	},
+}
+
+// readPipelineFile reads a pipeline in the YAML form written by a bundle
+func readPipelineFile(path string) (*codefresh.Pipeline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := &codefresh.Pipeline{}
+	if err := yaml.Unmarshal(data, pipeline); err != nil {
+		return nil, fmt.Errorf("failed to read pipeline from %s: %w", path, err)
+	}
+	return pipeline, nil
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "path to the pipeline (required)")
+	applyCmd.MarkFlagRequired("file")
+}
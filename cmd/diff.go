@@ -0,0 +1,53 @@
+// Copyright © 2019 Codefresh.Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codefresh-io/go-sdk/internal"
+	"github.com/codefresh-io/go-sdk/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var diffFile string
+
+// diffCmd shows what apply would change
+var diffCmd = &cobra.Command{
+	Use:     "diff",
+	Example: "cfctl diff -f pipeline.yaml",
+	Short:   "Show the changes apply would make to a pipeline",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := viper.Get("codefresh")
+		codefreshClient := utils.CastToCodefreshOrDie(client)
+		desired, err := readPipelineFile(diffFile)
+		internal.DieOnError(err)
+		diff, err := codefreshClient.Pipelines().DiffWithContext(context.Background(), desired)
+		internal.DieOnError(err)
+		if len(diff) == 0 {
+			fmt.Printf("Pipeline %s is up to date\n", desired.Metadata.Name)
+			return
+		}
+		fmt.Println(diff)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVarP(&diffFile, "file", "f", "", "path to the pipeline (required)")
+	diffCmd.MarkFlagRequired("file")
+}
@@ -0,0 +1,57 @@
+// Copyright © 2019 Codefresh.Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/codefresh-io/go-sdk/pkg/cfyaml"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFile   string
+	graphFormat string
+)
+
+// graphCmd prints the execution plan of a codefresh.yml
+var graphCmd = &cobra.Command{
+	Use:               "graph",
+	Short:             "Render the step graph of a codefresh.yml",
+	PersistentPreRunE: offline,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := ioutil.ReadFile(graphFile)
+		if err != nil {
+			return err
+		}
+		doc, diags := cfyaml.Parse(data)
+		if doc == nil {
+			return diags
+		}
+		out, err := doc.Graph().Render(cfyaml.Format(graphFormat))
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().StringVarP(&graphFile, "file", "f", "codefresh.yml", "path to the pipeline definition")
+	graphCmd.Flags().StringVarP(&graphFormat, "output", "o", string(cfyaml.FormatASCII), "output format: ascii, dot or mermaid")
+}
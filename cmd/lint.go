@@ -0,0 +1,57 @@
+// Copyright © 2019 Codefresh.Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/codefresh-io/go-sdk/pkg/cfyaml"
+	"github.com/spf13/cobra"
+)
+
+var lintFile string
+
+// lintCmd validates a codefresh.yml without contacting Codefresh
+var lintCmd = &cobra.Command{
+	Use:               "lint",
+	Short:             "Validate a codefresh.yml offline",
+	PersistentPreRunE: offline,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := ioutil.ReadFile(lintFile)
+		if err != nil {
+			return err
+		}
+		diags := cfyaml.Validate(data)
+		for _, diag := range diags {
+			fmt.Printf("%s:%s\n", lintFile, diag)
+		}
+		if diags.HasErrors() {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%s is not valid", lintFile)
+		}
+		return nil
+	},
+}
+
+// offline replaces the root PersistentPreRunE for commands that do not need a client
+func offline(cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVarP(&lintFile, "file", "f", "codefresh.yml", "path to the pipeline definition")
+}
@@ -0,0 +1,57 @@
+// Copyright © 2019 Codefresh.Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codefresh-io/go-sdk/internal"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"github.com/codefresh-io/go-sdk/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	logsFollow bool
+	logsSteps  []string
+)
+
+// logsCmd prints the output of a build
+var logsCmd = &cobra.Command{
+	Use:   "logs <build-id>",
+	Short: "Print the logs of a build",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := viper.Get("codefresh")
+		codefreshClient := utils.CastToCodefreshOrDie(client)
+		opt := &codefresh.LogOptions{
+			Follow: logsFollow,
+			Steps:  logsSteps,
+		}
+		err := codefreshClient.Logs().Follow(context.Background(), args[0], opt, func(line codefresh.LogLine) error {
+			fmt.Println(line.Text)
+			return nil
+		})
+		internal.DieOnError(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "keep printing new output until the build finishes")
+	logsCmd.Flags().StringSliceVar(&logsSteps, "step", nil, "only print the output of these steps")
+}
@@ -0,0 +1,70 @@
+// Copyright © 2019 Codefresh.Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/codefresh-io/go-sdk/internal"
+	"github.com/codefresh-io/go-sdk/pkg/metrics"
+	"github.com/codefresh-io/go-sdk/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	metricsListen    string
+	metricsInterval  time.Duration
+	metricsWindow    time.Duration
+	metricsPipelines []string
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Export build metrics",
+}
+
+// metricsServeCmd serves the build metrics for Prometheus to scrape
+var metricsServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve build metrics in the Prometheus and OpenMetrics formats",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := viper.Get("codefresh")
+		codefreshClient := utils.CastToCodefreshOrDie(client)
+		exporter := metrics.NewExporter(codefreshClient.Workflows(), &metrics.Options{
+			Interval:  metricsInterval,
+			Window:    metricsWindow,
+			Pipelines: metricsPipelines,
+		})
+		go exporter.Run(context.Background())
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exporter)
+		fmt.Printf("Serving build metrics on %s/metrics\n", metricsListen)
+		internal.DieOnError(http.ListenAndServe(metricsListen, mux))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.AddCommand(metricsServeCmd)
+	metricsServeCmd.Flags().StringVar(&metricsListen, "listen", ":9090", "address to serve the metrics on")
+	metricsServeCmd.Flags().DurationVar(&metricsInterval, "interval", time.Minute, "how often builds are collected")
+	metricsServeCmd.Flags().DurationVar(&metricsWindow, "window", 24*time.Hour, "only count builds created within this window")
+	metricsServeCmd.Flags().StringSliceVar(&metricsPipelines, "pipeline", nil, "pipeline ids to collect, all pipelines when empty")
+}
@@ -15,8 +15,12 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"time"
 
+	"github.com/codefresh-io/go-sdk/internal"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
 	"github.com/codefresh-io/go-sdk/pkg/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -28,7 +32,14 @@ var waitCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		client := viper.Get("codefresh")
 		codefreshClient := utils.CastToCodefreshOrDie(client)
-		codefreshClient.Workflows().WaitForStatus(args[0], "success", 2*time.Second, 5*time.Minute)
+		res, err := codefreshClient.Workflows().WaitForCompletionWithContext(context.Background(), args[0], &codefresh.WaitOptions{
+			Timeout: 5 * time.Minute,
+		})
+		internal.DieOnError(err)
+		if !res.Succeeded() {
+			internal.DieOnError(fmt.Errorf("Build %s finished with status %s", args[0], res.Status))
+		}
+		fmt.Printf("Build %s finished with status %s\n", args[0], res.Status)
 	},
 }
 
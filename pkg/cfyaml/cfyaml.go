@@ -0,0 +1,309 @@
+// Package cfyaml parses and validates codefresh.yml pipeline definitions offline
+package cfyaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	// v3 rather than the v2 used elsewhere: only its node tree carries the line and column of every element
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DefaultStage is the stage of steps that do not declare one
+const DefaultStage = "default"
+
+type (
+	Severity int
+
+	Position struct {
+		Line   int
+		Column int
+	}
+
+	// Diagnostic is a single problem found in a codefresh.yml
+	Diagnostic struct {
+		Severity Severity
+		Position Position
+		// Path - dotted path of the offending element, e.g. steps.build.image_name
+		Path    string
+		Message string
+	}
+
+	Diagnostics []Diagnostic
+
+	// Document is a parsed codefresh.yml
+	Document struct {
+		Version string
+		Spec    *codefresh.PipelineSpec
+
+		positions map[string]Position
+		parents   map[string]*codefresh.Step
+	}
+
+	// Stage groups the top level steps that belong to it, in order
+	Stage struct {
+		Name  string
+		Steps []*codefresh.Step
+	}
+)
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+func (s Severity) String() string {
+	return [...]string{"error", "warning"}[s]
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+func (d Diagnostic) String() string {
+	if d.Path == "" {
+		return fmt.Sprintf("%s: %s: %s", d.Position, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", d.Position, d.Severity, d.Path, d.Message)
+}
+
+// HasErrors returns true when at least one diagnostic is an error
+func (d Diagnostics) HasErrors() bool {
+	for _, diag := range d {
+		if diag.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diag := range d {
+		lines[i] = diag.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Parse decodes codefresh.yml content, the document is nil when the content is not valid YAML
+// or does not match the shape of a pipeline
+func Parse(data []byte) (*Document, Diagnostics) {
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(data, root); err != nil {
+		return nil, Diagnostics{syntaxDiagnostic(err)}
+	}
+	if len(root.Content) == 0 {
+		return nil, Diagnostics{{Severity: SeverityError, Position: Position{1, 1}, Message: "document is empty"}}
+	}
+	top := root.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return nil, Diagnostics{{Severity: SeverityError, Position: nodePosition(top), Message: "document must be a mapping"}}
+	}
+
+	doc := &Document{
+		Spec:      &codefresh.PipelineSpec{},
+		positions: map[string]Position{},
+		parents:   map[string]*codefresh.Step{},
+	}
+	indexPositions(top, "", doc.positions)
+	if err := decodeNode(top, doc.Spec); err != nil {
+		// in document order, the walk follows the yaml tree
+		return nil, decodeDiagnostics(top, "", reflect.TypeOf(codefresh.PipelineSpec{}), doc.positions)
+	}
+	if version, ok := doc.Spec.Extra["version"]; ok {
+		doc.Version, _ = strconv.Unquote(string(version))
+		if doc.Version == "" {
+			doc.Version = string(version)
+		}
+	}
+	for _, step := range doc.Spec.Steps {
+		doc.indexParents(step)
+	}
+	return doc, nil
+}
+
+// Validate parses and checks codefresh.yml content
+func Validate(data []byte) Diagnostics {
+	doc, diags := Parse(data)
+	if doc == nil {
+		return diags
+	}
+	return append(diags, doc.Validate()...)
+}
+
+// ValidatePipeline checks the original YAML of a pipeline fetched from the API
+func ValidatePipeline(p *codefresh.Pipeline) Diagnostics {
+	return Validate([]byte(p.Metadata.OriginalYamlString))
+}
+
+// Position returns the location of the element at the dotted path, falling back to its closest parent
+func (d *Document) Position(path string) Position {
+	for path != "" {
+		if pos, ok := d.positions[path]; ok {
+			return pos
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return Position{Line: 1, Column: 1}
+}
+
+// Step finds a step by name at any nesting level
+func (d *Document) Step(name string) *codefresh.Step {
+	var found *codefresh.Step
+	walkSteps(d.Spec.Steps, func(step *codefresh.Step) {
+		if found == nil && step.Name == name {
+			found = step
+		}
+	})
+	return found
+}
+
+// Parent returns the parallel step that contains the named step, nil for top level steps
+func (d *Document) Parent(name string) *codefresh.Step {
+	return d.parents[name]
+}
+
+// StageOf returns the stage a step runs in, nested steps belong to the stage of their parallel parent
+func (d *Document) StageOf(step *codefresh.Step) string {
+	for step != nil {
+		if step.Stage != "" {
+			return step.Stage
+		}
+		step = d.parents[step.Name]
+	}
+	return DefaultStage
+}
+
+// Stages returns the declared stages with their top level steps, followed by the default stage when used
+func (d *Document) Stages() []Stage {
+	stages := []Stage{}
+	index := map[string]int{}
+	for _, name := range d.Spec.Stages {
+		index[name] = len(stages)
+		stages = append(stages, Stage{Name: name})
+	}
+	for _, step := range d.Spec.Steps {
+		name := d.StageOf(step)
+		i, ok := index[name]
+		if !ok {
+			i = len(stages)
+			index[name] = i
+			stages = append(stages, Stage{Name: name})
+		}
+		stages[i].Steps = append(stages[i].Steps, step)
+	}
+	return stages
+}
+
+// stepPath returns the dotted path of a step, nested steps are under their parent
+func (d *Document) stepPath(step *codefresh.Step) string {
+	path := "steps." + step.Name
+	for parent := d.parents[step.Name]; parent != nil; parent = d.parents[parent.Name] {
+		path = "steps." + parent.Name + "." + path
+	}
+	return path
+}
+
+func (d *Document) indexParents(step *codefresh.Step) {
+	for _, child := range step.Steps {
+		d.parents[child.Name] = step
+		d.indexParents(child)
+	}
+}
+
+func walkSteps(steps codefresh.Steps, fn func(*codefresh.Step)) {
+	for _, step := range steps {
+		fn(step)
+		walkSteps(step.Steps, fn)
+	}
+}
+
+func indexPositions(node *yaml.Node, path string, positions map[string]Position) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := key.Value
+			if path != "" {
+				child = path + "." + key.Value
+			}
+			positions[child] = nodePosition(key)
+			indexPositions(value, child, positions)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			child := fmt.Sprintf("%s.%d", path, i)
+			positions[child] = nodePosition(item)
+			indexPositions(item, child, positions)
+		}
+	}
+}
+
+func writeNodeAsJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return writeNodeAsJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeNodeAsJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeNodeAsJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeNodeAsJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+func nodePosition(node *yaml.Node) Position {
+	return Position{Line: node.Line, Column: node.Column}
+}
+
+func syntaxDiagnostic(err error) Diagnostic {
+	pos := Position{Line: 1, Column: 1}
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		pos.Line, _ = strconv.Atoi(m[1])
+	}
+	return Diagnostic{Severity: SeverityError, Position: pos, Message: err.Error()}
+}
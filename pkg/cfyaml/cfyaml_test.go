@@ -0,0 +1,225 @@
+package cfyaml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validPipeline = `version: "1.0"
+stages:
+  - prepare
+  - build
+steps:
+  clone:
+    type: git-clone
+    stage: prepare
+    repo: codefresh-io/go-sdk
+    revision: ${{CF_BRANCH}}
+  checks:
+    type: parallel
+    stage: build
+    steps:
+      lint:
+        image: golang:1.16
+        commands:
+          - make lint
+      test:
+        image: golang:1.16
+        commands:
+          - make test
+  build:
+    type: build
+    stage: build
+    image_name: codefresh/go-sdk
+    tag: ${{CF_SHORT_REVISION}}
+    when:
+      steps:
+        - name: checks
+          on:
+            - success
+      condition:
+        all:
+          onMaster: "'${{CF_BRANCH}}' == 'master' && !${{SKIP_BUILD}}"
+`
+
+func TestParse(t *testing.T) {
+	doc, diags := Parse([]byte(validPipeline))
+	assert.Empty(t, diags)
+	assert.Equal(t, "1.0", doc.Version)
+
+	names := []string{}
+	for _, step := range doc.Spec.Steps {
+		names = append(names, step.Name)
+	}
+	assert.Equal(t, []string{"clone", "checks", "build"}, names)
+
+	assert.Equal(t, "checks", doc.Parent("lint").Name)
+	assert.Nil(t, doc.Parent("checks"))
+	assert.Equal(t, "build", doc.StageOf(doc.Step("test")))
+
+	stages := doc.Stages()
+	assert.Len(t, stages, 2)
+	assert.Equal(t, "build", stages[1].Name)
+	assert.Len(t, stages[1].Steps, 2)
+
+	assert.Equal(t, Position{Line: 19, Column: 7}, doc.Position("steps.checks.steps.test"))
+	assert.Equal(t, Position{Line: 19, Column: 7}, doc.Position("steps.checks.steps.test.unknown"))
+}
+
+func TestParseDecodeErrors(t *testing.T) {
+	cases := map[string]struct {
+		yaml string
+		want []string
+	}{
+		"scalar instead of list": {
+			yaml: "version: '1.0'\nsteps:\n  test:\n    image: alpine\n    commands: make test\n",
+			want: []string{"5:5: error: steps.test.commands: expected a list, got a string"},
+		},
+		"steps as a list": {
+			yaml: "version: '1.0'\nsteps:\n  - test:\n      image: alpine\n",
+			want: []string{"2:1: error: steps: expected a mapping of step names to steps, got a list"},
+		},
+		"nested step": {
+			yaml: "version: '1.0'\nsteps:\n  checks:\n    type: parallel\n    steps:\n      lint:\n        image: alpine\n        commands:\n          lint: true\n",
+			want: []string{"8:9: error: steps.checks.steps.lint.commands: expected a list, got a mapping"},
+		},
+		"several errors": {
+			yaml: "version: '1.0'\nstages: build\nsteps:\n  test:\n    image: alpine\n    when:\n      branch:\n        only: main\n",
+			want: []string{
+				"2:1: error: stages: expected a list, got a string",
+				"8:9: error: steps.test.when.branch.only: expected a list, got a string",
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			doc, diags := Parse([]byte(c.yaml))
+			assert.Nil(t, doc)
+			got := []string{}
+			for _, d := range diags {
+				got = append(got, d.String())
+			}
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		yaml     string
+		messages []string
+	}{
+		"valid": {
+			yaml: validPipeline,
+		},
+		"syntax error": {
+			yaml:     "steps:\n  a: [\n",
+			messages: []string{"2:1: error: yaml: line 2:"},
+		},
+		"missing version and steps": {
+			yaml: "stages: []\n",
+			messages: []string{
+				"1:1: warning: version: version is not set",
+				"1:1: error: steps: pipeline has no steps",
+			},
+		},
+		"required fields": {
+			yaml: `version: "1.0"
+steps:
+  free:
+    commands: [ls]
+  push:
+    type: push
+  custom:
+    type: codefresh/slack
+`,
+			messages: []string{
+				`3:3: error: steps.free: freestyle step "free" requires "image"`,
+				`5:3: error: steps.push: push step "push" requires "candidate"`,
+			},
+		},
+		"stages and references": {
+			yaml: `version: "1.0"
+stages: [build]
+steps:
+  a:
+    image: alpine
+    stage: test
+  b:
+    image: alpine
+    when:
+      steps:
+        - name: c
+          on: [done]
+`,
+			messages: []string{
+				`6:5: error: steps.a.stage: stage "test" is not declared in stages`,
+				`7:3: warning: steps.b: step "b" has no stage`,
+				`11:11: error: steps.b.when.steps.0: unknown step "c"`,
+				`11:11: error: steps.b.when.steps.0: unknown step state "done"`,
+			},
+		},
+		"interpolation and conditions": {
+			yaml: `version: "1.0"
+steps:
+  a:
+    image: alpine:${{TAG
+    commands:
+      - echo ${{}} ${{1BAD}}
+    when:
+      condition:
+        any:
+          broken: "'${{CF_BRANCH}}' == "
+`,
+			messages: []string{
+				`4:5: error: steps.a.image: unterminated variable reference`,
+				`6:9: error: steps.a.commands.0: empty variable reference`,
+				`6:9: error: steps.a.commands.0: invalid variable name "1BAD"`,
+				`10:11: error: steps.a.when.condition.any.broken: invalid condition: unexpected "end of expression"`,
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			diags := Validate([]byte(tt.yaml))
+			assert.Len(t, diags, len(tt.messages), diags.Error())
+			for i, msg := range tt.messages {
+				if i < len(diags) {
+					assert.True(t, strings.HasPrefix(diags[i].String(), msg), "%q does not start with %q", diags[i].String(), msg)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	valid := []string{
+		"'${{CF_BRANCH}}' == 'master'",
+		"${{steps.build.result}} == \"success\" || (${{X}} > 1.5 && !false)",
+		"includes('${{CF_COMMIT_MESSAGE}}', '[skip]') == false",
+		"match('${{CF_BRANCH}}', '^release', true)",
+		"${{BUILD_NUMBER}} % 2 == 0",
+		"${{A}} + ${{B}} * 2 >= 10 - -1",
+		"(${{A}} - 1) / 2 < 3 && ${{B}} != 0",
+	}
+	for _, expr := range valid {
+		assert.NoError(t, ParseCondition(expr), expr)
+	}
+
+	invalid := []string{
+		"",
+		"'a' ==",
+		"('a' == 'b'",
+		"'unterminated",
+		"a = b",
+		"f(a b)",
+		"1 +",
+		"* 2",
+		"1 ** 2",
+	}
+	for _, expr := range invalid {
+		assert.Error(t, ParseCondition(expr), expr)
+	}
+}
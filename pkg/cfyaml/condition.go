@@ -0,0 +1,271 @@
+package cfyaml
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Grammar of when.condition expressions:
+//
+//	expr           = or
+//	or             = and { "||" and }
+//	and            = comparison { "&&" comparison }
+//	comparison     = additive [ ( "==" | "!=" | "<" | ">" | "<=" | ">=" ) additive ]
+//	additive       = multiplicative { ( "+" | "-" ) multiplicative }
+//	multiplicative = unary { ( "*" | "/" | "%" ) unary }
+//	unary          = ( "!" | "-" | "+" ) unary | primary
+//	primary        = number | string | "true" | "false" | ident [ "(" [ expr { "," expr } ] ")" ]
+//	               | "${{" name "}}" | "(" expr ")"
+
+type (
+	tokenKind int
+
+	token struct {
+		kind  tokenKind
+		value string
+		pos   int
+	}
+
+	conditionParser struct {
+		tokens []token
+		next   int
+	}
+)
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenVariable
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%"}
+
+// ParseCondition checks that expr is a valid when.condition expression
+func ParseCondition(expr string) error {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return err
+	}
+	p := &conditionParser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return fmt.Errorf("empty expression")
+	}
+	if err := p.parseOr(); err != nil {
+		return err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return fmt.Errorf("unexpected %q at offset %d", t.value, t.pos)
+	}
+	return nil
+}
+
+func tokenize(expr string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(expr[i:], "${{"):
+			end := strings.Index(expr[i:], "}}")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated variable reference at offset %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenVariable, value: expr[i : i+end+2], pos: i})
+			i += end + 2
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: expr[i : i+end+2], pos: i})
+			i += end + 2
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(expr) && (expr[i] >= '0' && expr[i] <= '9' || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: expr[start:i], pos: start})
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(expr) && (expr[i] == '_' || expr[i] == '.' || unicode.IsLetter(rune(expr[i])) || unicode.IsDigit(rune(expr[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: expr[start:i], pos: start})
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: i})
+			i++
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, value: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, value: "end of expression", pos: len(expr)}), nil
+}
+
+func (p *conditionParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *conditionParser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *conditionParser) isOperator(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.value == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *conditionParser) parseOr() error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for p.isOperator("||") {
+		p.advance()
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *conditionParser) parseAnd() error {
+	if err := p.parseComparison(); err != nil {
+		return err
+	}
+	for p.isOperator("&&") {
+		p.advance()
+		if err := p.parseComparison(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *conditionParser) parseComparison() error {
+	if err := p.parseAdditive(); err != nil {
+		return err
+	}
+	if p.isOperator("==", "!=", "<", ">", "<=", ">=") {
+		p.advance()
+		return p.parseAdditive()
+	}
+	return nil
+}
+
+func (p *conditionParser) parseAdditive() error {
+	if err := p.parseMultiplicative(); err != nil {
+		return err
+	}
+	for p.isOperator("+", "-") {
+		p.advance()
+		if err := p.parseMultiplicative(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *conditionParser) parseMultiplicative() error {
+	if err := p.parseUnary(); err != nil {
+		return err
+	}
+	for p.isOperator("*", "/", "%") {
+		p.advance()
+		if err := p.parseUnary(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *conditionParser) parseUnary() error {
+	if p.isOperator("!", "-", "+") {
+		p.advance()
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *conditionParser) parsePrimary() error {
+	t := p.advance()
+	switch t.kind {
+	case tokenNumber, tokenString:
+		return nil
+	case tokenVariable:
+		if errs := CheckInterpolation(t.value); len(errs) > 0 {
+			return errs[0]
+		}
+		return nil
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			p.advance()
+			return p.parseArguments()
+		}
+		return nil
+	case tokenLParen:
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		if r := p.advance(); r.kind != tokenRParen {
+			return fmt.Errorf("expected \")\" at offset %d, got %q", r.pos, r.value)
+		}
+		return nil
+	}
+	return fmt.Errorf("unexpected %q at offset %d", t.value, t.pos)
+}
+
+func (p *conditionParser) parseArguments() error {
+	if p.peek().kind == tokenRParen {
+		p.advance()
+		return nil
+	}
+	for {
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		t := p.advance()
+		switch t.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return nil
+		}
+		return fmt.Errorf("expected \",\" or \")\" at offset %d, got %q", t.pos, t.value)
+	}
+}
@@ -0,0 +1,156 @@
+package cfyaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	stepsGoType = reflect.TypeOf(codefresh.Steps{})
+	stepGoType  = reflect.TypeOf(codefresh.Step{})
+	// timeouts are a duration string, a number or an approval timeout mapping
+	timeoutGoType = reflect.TypeOf(codefresh.StepTimeout{})
+)
+
+// decodeNode decodes a yaml node through JSON, which keeps the order of the steps
+func decodeNode(node *yaml.Node, target interface{}) error {
+	buf := &bytes.Buffer{}
+	if err := writeNodeAsJSON(buf, node); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf.Bytes(), target)
+}
+
+// decodeDiagnostics reports the smallest elements of node that fail to decode into a value of type t,
+// walking the yaml tree alongside the Go type so every diagnostic points at the offending line
+func decodeDiagnostics(node *yaml.Node, path string, t reflect.Type, positions map[string]Position) Diagnostics {
+	err := decodeNode(node, reflect.New(t).Interface())
+	if err == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	found := Diagnostics{}
+	switch {
+	case t == stepsGoType && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			found = append(found, decodeDiagnostics(node.Content[i+1], joinPath(path, node.Content[i].Value), stepGoType, positions)...)
+		}
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			// unknown keys are kept as is, they never fail to decode
+			if ft, ok := jsonFieldType(t, key); ok {
+				found = append(found, decodeDiagnostics(node.Content[i+1], joinPath(path, key), ft, positions)...)
+			}
+		}
+	case t.Kind() == reflect.Slice && t != stepsGoType && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			found = append(found, decodeDiagnostics(item, fmt.Sprintf("%s.%d", path, i), t.Elem(), positions)...)
+		}
+	}
+	if len(found) > 0 {
+		return found
+	}
+
+	pos, ok := positions[path]
+	if !ok {
+		pos = nodePosition(node)
+	}
+	return Diagnostics{{Severity: SeverityError, Position: pos, Path: path, Message: decodeMessage(err, node, t)}}
+}
+
+// decodeMessage describes a decode error in yaml terms rather than Go ones
+func decodeMessage(err error, node *yaml.Node, t reflect.Type) string {
+	want := describeType(t)
+	typeErr := &json.UnmarshalTypeError{}
+	if errors.As(err, &typeErr) || t == stepsGoType || node.Kind != expectedKind(t) {
+		if want != "" {
+			return fmt.Sprintf("expected %s, got %s", want, describeNode(node))
+		}
+	}
+	return strings.TrimPrefix(err.Error(), "json: ")
+}
+
+func describeType(t reflect.Type) string {
+	switch t {
+	case stepsGoType:
+		return "a mapping of step names to steps"
+	case timeoutGoType:
+		return "a duration, a number or a mapping"
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "a mapping"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return ""
+}
+
+func expectedKind(t reflect.Type) yaml.Kind {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return yaml.SequenceNode
+	case reflect.Map, reflect.Struct:
+		return yaml.MappingNode
+	}
+	return yaml.ScalarNode
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		return "a number"
+	case "!!bool":
+		return "a boolean"
+	case "!!null":
+		return "null"
+	}
+	return "a string"
+}
+
+// jsonFieldType returns the type of the struct field encoded under key
+func jsonFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == key && name != "-" {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
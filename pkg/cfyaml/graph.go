@@ -0,0 +1,247 @@
+package cfyaml
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+)
+
+type (
+	EdgeKind int
+
+	// Node is a step of the execution graph
+	Node struct {
+		Name  string
+		Type  string
+		Stage string
+		// Parent - name of the parallel step containing this one, empty for top level steps
+		Parent string
+		Step   *codefresh.Step
+	}
+
+	// Edge means To can only start once From is done
+	Edge struct {
+		From string
+		To   string
+		Kind EdgeKind
+		// On - states of From that satisfy a when dependency
+		On []string
+	}
+
+	// Graph is the execution DAG of a pipeline, nodes are in declaration order
+	Graph struct {
+		Nodes  []*Node
+		Edges  []*Edge
+		Stages []string
+
+		index map[string]*Node
+	}
+)
+
+const (
+	// EdgeSequential - implicit ordering of steps in a sequential pipeline
+	EdgeSequential EdgeKind = iota
+	// EdgeParallel - a parallel step starting one of its children
+	EdgeParallel
+	// EdgeDependency - an explicit when.steps dependency
+	EdgeDependency
+)
+
+func (k EdgeKind) String() string {
+	return [...]string{"sequential", "parallel", "dependency"}[k]
+}
+
+// Graph returns the execution graph of the document
+func (d *Document) Graph() *Graph {
+	return NewGraph(d.Spec)
+}
+
+// NewGraph computes the execution graph of a pipeline spec, dependencies on unknown steps are ignored
+func NewGraph(spec *codefresh.PipelineSpec) *Graph {
+	g := &Graph{index: map[string]*Node{}}
+	var add func(steps codefresh.Steps, parent *Node, stage string)
+	add = func(steps codefresh.Steps, parent *Node, stage string) {
+		for _, step := range steps {
+			node := &Node{Name: step.Name, Type: stepType(step), Stage: stage, Step: step}
+			if step.Stage != "" {
+				node.Stage = step.Stage
+			}
+			if parent != nil {
+				node.Parent = parent.Name
+			}
+			g.Nodes = append(g.Nodes, node)
+			g.index[step.Name] = node
+			add(step.Steps, node, node.Stage)
+		}
+	}
+	add(spec.Steps, nil, DefaultStage)
+	g.Stages = graphStages(spec, g.Nodes)
+
+	sequential := spec.Mode != codefresh.PipelineModeParallel
+	g.link(spec.Steps, nil, sequential)
+	return g
+}
+
+// Node returns the node of the named step, or nil
+func (g *Graph) Node(name string) *Node {
+	return g.index[name]
+}
+
+// Children returns the steps directly inside the named parallel step
+func (g *Graph) Children(name string) []*Node {
+	children := []*Node{}
+	for _, node := range g.Nodes {
+		if node.Parent == name {
+			children = append(children, node)
+		}
+	}
+	return children
+}
+
+// DependsOn returns the edges ending at the named step
+func (g *Graph) DependsOn(name string) []*Edge {
+	edges := []*Edge{}
+	for _, edge := range g.Edges {
+		if edge.To == name {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// Order returns the step names in an order that satisfies every edge, parallel steps come before their children
+func (g *Graph) Order() ([]string, error) {
+	incoming := map[string]int{}
+	for _, edge := range g.Edges {
+		incoming[edge.To]++
+	}
+	ready := []string{}
+	for _, node := range g.Nodes {
+		if incoming[node.Name] == 0 {
+			ready = append(ready, node.Name)
+		}
+	}
+	order := []string{}
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, edge := range g.Edges {
+			if edge.From != name {
+				continue
+			}
+			incoming[edge.To]--
+			if incoming[edge.To] == 0 {
+				ready = append(ready, edge.To)
+			}
+		}
+	}
+	if len(order) != len(g.Nodes) {
+		cycle := []string{}
+		for _, node := range g.Nodes {
+			if incoming[node.Name] > 0 {
+				cycle = append(cycle, node.Name)
+			}
+		}
+		return nil, fmt.Errorf("steps %v have circular dependencies", cycle)
+	}
+	return order, nil
+}
+
+// link adds the edges of a list of sibling steps, entry is the parallel step that starts them
+func (g *Graph) link(steps codefresh.Steps, entry *Node, sequential bool) {
+	var previous *codefresh.Step
+	for _, step := range steps {
+		explicit := g.linkDependencies(step)
+		switch {
+		case sequential && previous != nil:
+			for _, sink := range g.sinks(previous) {
+				g.addEdge(&Edge{From: sink, To: step.Name, Kind: EdgeSequential})
+			}
+		case !explicit && entry != nil:
+			g.addEdge(&Edge{From: entry.Name, To: step.Name, Kind: EdgeParallel})
+		}
+		if len(step.Steps) > 0 {
+			g.link(step.Steps, g.index[step.Name], false)
+		}
+		previous = step
+	}
+}
+
+// linkDependencies adds the when.steps edges of step and reports whether it has any
+func (g *Graph) linkDependencies(step *codefresh.Step) bool {
+	if step.When == nil || step.When.Steps == nil {
+		return false
+	}
+	found := false
+	deps := append(append([]codefresh.WhenStep{}, step.When.Steps.All...), step.When.Steps.Any...)
+	for _, dep := range deps {
+		if g.index[dep.Name] == nil {
+			continue
+		}
+		on := dep.On
+		if len(on) == 0 {
+			on = []string{"success"}
+		}
+		g.addEdge(&Edge{From: dep.Name, To: step.Name, Kind: EdgeDependency, On: on})
+		found = true
+	}
+	return found
+}
+
+// addEdge keeps a single edge per pair of steps, an explicit dependency wins over an implicit one
+func (g *Graph) addEdge(edge *Edge) {
+	for i, existing := range g.Edges {
+		if existing.From == edge.From && existing.To == edge.To {
+			if edge.Kind == EdgeDependency {
+				g.Edges[i] = edge
+			}
+			return
+		}
+	}
+	g.Edges = append(g.Edges, edge)
+}
+
+// sinks returns the steps that must be done for step to be done
+func (g *Graph) sinks(step *codefresh.Step) []string {
+	if len(step.Steps) == 0 {
+		return []string{step.Name}
+	}
+	needed := map[string]bool{}
+	for _, child := range step.Steps {
+		if child.When == nil || child.When.Steps == nil {
+			continue
+		}
+		for _, dep := range append(append([]codefresh.WhenStep{}, child.When.Steps.All...), child.When.Steps.Any...) {
+			needed[dep.Name] = true
+		}
+	}
+	sinks := []string{}
+	for _, child := range step.Steps {
+		if !needed[child.Name] {
+			sinks = append(sinks, g.sinks(child)...)
+		}
+	}
+	return sinks
+}
+
+// graphStages returns the declared stages followed by the undeclared ones in order of use
+func graphStages(spec *codefresh.PipelineSpec, nodes []*Node) []string {
+	stages := append([]string{}, spec.Stages...)
+	known := map[string]bool{}
+	for _, name := range stages {
+		known[name] = true
+	}
+	extra := []string{}
+	for _, node := range nodes {
+		if !known[node.Stage] {
+			known[node.Stage] = true
+			extra = append(extra, node.Stage)
+		}
+	}
+	sort.SliceStable(extra, func(i, j int) bool {
+		return extra[i] != DefaultStage && extra[j] == DefaultStage
+	})
+	return append(stages, extra...)
+}
@@ -0,0 +1,103 @@
+package cfyaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func edgeStrings(g *Graph) []string {
+	edges := []string{}
+	for _, edge := range g.Edges {
+		edges = append(edges, edge.From+" -> "+edge.To+" "+edge.Kind.String())
+	}
+	return edges
+}
+
+func TestGraph(t *testing.T) {
+	doc, diags := Parse([]byte(validPipeline))
+	assert.Empty(t, diags)
+	g := doc.Graph()
+
+	assert.Equal(t, []string{"prepare", "build"}, g.Stages)
+	assert.Equal(t, []string{
+		"clone -> checks sequential",
+		"checks -> lint parallel",
+		"checks -> test parallel",
+		"checks -> build dependency",
+		"lint -> build sequential",
+		"test -> build sequential",
+	}, edgeStrings(g))
+
+	order, err := g.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"clone", "checks", "lint", "test", "build"}, order)
+
+	assert.Equal(t, "prepare\n"+
+		"`-- clone (git-clone)\n"+
+		"build\n"+
+		"|-- checks (parallel)\n"+
+		"|   |-- lint (freestyle)\n"+
+		"|   `-- test (freestyle)\n"+
+		"`-- build (build) after checks[success]\n", g.ASCII())
+}
+
+func TestGraphParallelMode(t *testing.T) {
+	doc, diags := Parse([]byte(`version: "1.0"
+mode: parallel
+steps:
+  a:
+    image: alpine
+  b:
+    image: alpine
+    when:
+      steps:
+        - name: a
+          on: [success]
+  c:
+    image: alpine
+    when:
+      steps:
+        - name: b
+          on: [failure]
+`))
+	assert.Empty(t, diags)
+	g := doc.Graph()
+	assert.Equal(t, []string{"a -> b dependency", "b -> c dependency"}, edgeStrings(g))
+	assert.Equal(t, []string{"failure"}, g.DependsOn("c")[0].On)
+
+	assert.Equal(t, "digraph pipeline {\n"+
+		"  node [shape=box];\n"+
+		"  \"a\" [label=\"a\\n(freestyle)\"];\n"+
+		"  \"b\" [label=\"b\\n(freestyle)\"];\n"+
+		"  \"c\" [label=\"c\\n(freestyle)\"];\n"+
+		"  \"a\" -> \"b\" [style=dashed, label=\"success\"];\n"+
+		"  \"b\" -> \"c\" [style=dashed, label=\"failure\"];\n"+
+		"}\n", g.DOT())
+
+	assert.Equal(t, "flowchart TD\n"+
+		"  n0[\"a (freestyle)\"]\n"+
+		"  n1[\"b (freestyle)\"]\n"+
+		"  n2[\"c (freestyle)\"]\n"+
+		"  n0 -- \"success\" --> n1\n"+
+		"  n1 -- \"failure\" --> n2\n", g.Mermaid())
+
+	_, err := g.Render("svg")
+	assert.Error(t, err)
+}
+
+func TestGraphCycle(t *testing.T) {
+	doc, _ := Parse([]byte(`mode: parallel
+steps:
+  a:
+    image: alpine
+    when:
+      steps: [{name: b}]
+  b:
+    image: alpine
+    when:
+      steps: [{name: a}]
+`))
+	_, err := doc.Graph().Order()
+	assert.EqualError(t, err, "steps [a b] have circular dependencies")
+}
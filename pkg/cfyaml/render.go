@@ -0,0 +1,183 @@
+package cfyaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format is an output format of Graph.Render
+type Format string
+
+const (
+	FormatDOT     Format = "dot"
+	FormatMermaid Format = "mermaid"
+	FormatASCII   Format = "ascii"
+)
+
+// Render returns the graph in the requested format
+func (g *Graph) Render(format Format) (string, error) {
+	switch format {
+	case FormatDOT:
+		return g.DOT(), nil
+	case FormatMermaid:
+		return g.Mermaid(), nil
+	case FormatASCII:
+		return g.ASCII(), nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected one of %s, %s, %s", format, FormatDOT, FormatMermaid, FormatASCII)
+}
+
+// DOT renders the graph for Graphviz, stages are drawn as clusters
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph pipeline {\n")
+	sb.WriteString("  node [shape=box];\n")
+	if g.hasStages() {
+		for i, stage := range g.Stages {
+			nodes := g.stageNodes(stage)
+			if len(nodes) == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, "  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(&sb, "    label=%s;\n", strconv.Quote(stage))
+			for _, node := range nodes {
+				sb.WriteString("    " + dotNode(node) + "\n")
+			}
+			sb.WriteString("  }\n")
+		}
+	} else {
+		for _, node := range g.Nodes {
+			sb.WriteString("  " + dotNode(node) + "\n")
+		}
+	}
+	for _, edge := range g.Edges {
+		attrs := ""
+		switch edge.Kind {
+		case EdgeParallel:
+			attrs = " [style=dotted]"
+		case EdgeDependency:
+			attrs = fmt.Sprintf(" [style=dashed, label=%s]", strconv.Quote(strings.Join(edge.On, ",")))
+		}
+		fmt.Fprintf(&sb, "  %s -> %s%s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To), attrs)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, stages are drawn as subgraphs
+func (g *Graph) Mermaid() string {
+	ids := map[string]string{}
+	for i, node := range g.Nodes {
+		ids[node.Name] = fmt.Sprintf("n%d", i)
+	}
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	if g.hasStages() {
+		for i, stage := range g.Stages {
+			nodes := g.stageNodes(stage)
+			if len(nodes) == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, "  subgraph s%d [\"%s\"]\n", i, mermaidEscape(stage))
+			for _, node := range nodes {
+				sb.WriteString("    " + mermaidNode(ids[node.Name], node) + "\n")
+			}
+			sb.WriteString("  end\n")
+		}
+	} else {
+		for _, node := range g.Nodes {
+			sb.WriteString("  " + mermaidNode(ids[node.Name], node) + "\n")
+		}
+	}
+	for _, edge := range g.Edges {
+		switch edge.Kind {
+		case EdgeParallel:
+			fmt.Fprintf(&sb, "  %s -.-> %s\n", ids[edge.From], ids[edge.To])
+		case EdgeDependency:
+			fmt.Fprintf(&sb, "  %s -- \"%s\" --> %s\n", ids[edge.From], mermaidEscape(strings.Join(edge.On, ",")), ids[edge.To])
+		default:
+			fmt.Fprintf(&sb, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+		}
+	}
+	return sb.String()
+}
+
+// ASCII renders the steps as a tree grouped by stage, explicit dependencies are listed after each step
+func (g *Graph) ASCII() string {
+	var sb strings.Builder
+	if !g.hasStages() {
+		g.writeTree(&sb, g.Children(""), "")
+		return sb.String()
+	}
+	for _, stage := range g.Stages {
+		nodes := []*Node{}
+		for _, node := range g.stageNodes(stage) {
+			if node.Parent == "" {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+		sb.WriteString(stage + "\n")
+		g.writeTree(&sb, nodes, "")
+	}
+	return sb.String()
+}
+
+func (g *Graph) writeTree(sb *strings.Builder, nodes []*Node, indent string) {
+	for i, node := range nodes {
+		branch, next := "|-- ", "|   "
+		if i == len(nodes)-1 {
+			branch, next = "`-- ", "    "
+		}
+		sb.WriteString(indent + branch + node.Name + " (" + node.Type + ")")
+		deps := []string{}
+		for _, edge := range g.DependsOn(node.Name) {
+			if edge.Kind == EdgeDependency {
+				deps = append(deps, fmt.Sprintf("%s[%s]", edge.From, strings.Join(edge.On, ",")))
+			}
+		}
+		if len(deps) > 0 {
+			sb.WriteString(" after " + strings.Join(deps, ", "))
+		}
+		sb.WriteString("\n")
+		g.writeTree(sb, g.Children(node.Name), indent+next)
+	}
+}
+
+// hasStages is false when every step runs in the default stage
+func (g *Graph) hasStages() bool {
+	return !(len(g.Stages) == 1 && g.Stages[0] == DefaultStage)
+}
+
+func (g *Graph) stageNodes(stage string) []*Node {
+	nodes := []*Node{}
+	for _, node := range g.Nodes {
+		if node.Stage == stage {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func dotNode(node *Node) string {
+	attrs := fmt.Sprintf("label=%s", strconv.Quote(node.Name+"\n("+node.Type+")"))
+	if len(node.Step.Steps) > 0 {
+		attrs += ", shape=diamond"
+	}
+	return fmt.Sprintf("%s [%s];", strconv.Quote(node.Name), attrs)
+}
+
+func mermaidNode(id string, node *Node) string {
+	label := mermaidEscape(node.Name + " (" + node.Type + ")")
+	if len(node.Step.Steps) > 0 {
+		return fmt.Sprintf("%s{\"%s\"}", id, label)
+	}
+	return fmt.Sprintf("%s[\"%s\"]", id, label)
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, "\"", "#quot;")
+}
@@ -0,0 +1,265 @@
+package cfyaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+)
+
+const supportedVersion = "1.0"
+
+var (
+	variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z0-9_\-]+)*$`)
+
+	// dependency states accepted by when.steps[].on
+	stepStates = map[string]bool{
+		"success":  true,
+		"failure":  true,
+		"skipped":  true,
+		"finished": true,
+		"approved": true,
+		"denied":   true,
+	}
+)
+
+// validator collects the diagnostics of a single document
+type validator struct {
+	doc   *Document
+	diags Diagnostics
+}
+
+// Validate checks required fields, stages, step references, interpolations and conditions
+func (d *Document) Validate() Diagnostics {
+	v := &validator{doc: d, diags: Diagnostics{}}
+	v.checkVersion()
+	v.checkSteps()
+	v.checkInterpolations()
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		a, b := v.diags[i].Position, v.diags[j].Position
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.diags
+}
+
+func (v *validator) errorf(path string, format string, args ...interface{}) {
+	v.add(SeverityError, path, format, args...)
+}
+
+func (v *validator) warnf(path string, format string, args ...interface{}) {
+	v.add(SeverityWarning, path, format, args...)
+}
+
+func (v *validator) add(severity Severity, path string, format string, args ...interface{}) {
+	v.diags = append(v.diags, Diagnostic{
+		Severity: severity,
+		Position: v.doc.Position(path),
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) checkVersion() {
+	switch v.doc.Version {
+	case "":
+		v.warnf("version", "version is not set, %q is assumed", supportedVersion)
+	case supportedVersion:
+	default:
+		v.errorf("version", "unsupported version %q, expected %q", v.doc.Version, supportedVersion)
+	}
+}
+
+func (v *validator) checkSteps() {
+	if len(v.doc.Spec.Steps) == 0 {
+		v.errorf("steps", "pipeline has no steps")
+		return
+	}
+
+	stages := map[string]bool{}
+	for _, name := range v.doc.Spec.Stages {
+		stages[name] = true
+	}
+	seen := map[string]bool{}
+	walkSteps(v.doc.Spec.Steps, func(step *codefresh.Step) {
+		path := v.doc.stepPath(step)
+		if seen[step.Name] {
+			v.errorf(path, "duplicate step name %q", step.Name)
+		}
+		seen[step.Name] = true
+
+		v.checkRequired(step, path)
+		v.checkStage(step, path, stages)
+		if step.When != nil {
+			v.checkWhen(step.When, path+".when")
+		}
+	})
+}
+
+func (v *validator) checkRequired(step *codefresh.Step, path string) {
+	missing := func(field string) {
+		v.errorf(path, "%s step %q requires %q", stepType(step), step.Name, field)
+	}
+	switch stepType(step) {
+	case codefresh.StepTypeFreestyle:
+		if step.Image == "" {
+			missing("image")
+		}
+	case codefresh.StepTypeBuild:
+		if step.ImageName == "" {
+			missing("image_name")
+		}
+	case codefresh.StepTypePush:
+		if step.Candidate == "" {
+			missing("candidate")
+		}
+	case codefresh.StepTypeDeploy:
+		if step.Kind == "" {
+			missing("kind")
+		}
+	case codefresh.StepTypeComposition:
+		if len(step.Composition) == 0 {
+			missing("composition")
+		}
+	case codefresh.StepTypeParallel:
+		if len(step.Steps) == 0 {
+			missing("steps")
+		}
+	case codefresh.StepTypeGitClone:
+		if step.Repo == "" {
+			missing("repo")
+		}
+	}
+}
+
+func (v *validator) checkStage(step *codefresh.Step, path string, stages map[string]bool) {
+	if step.Stage == "" {
+		if len(stages) > 0 && v.doc.Parent(step.Name) == nil {
+			v.warnf(path, "step %q has no stage, it will run in the %q stage", step.Name, DefaultStage)
+		}
+		return
+	}
+	if !stages[step.Stage] {
+		v.errorf(path+".stage", "stage %q is not declared in stages", step.Stage)
+	}
+}
+
+func (v *validator) checkWhen(when *codefresh.When, path string) {
+	if when.Steps != nil {
+		v.checkWhenSteps(when.Steps.All, path+".steps", "all")
+		v.checkWhenSteps(when.Steps.Any, path+".steps", "any")
+	}
+	if when.Condition != nil {
+		v.checkConditions(when.Condition.All, path+".condition.all")
+		v.checkConditions(when.Condition.Any, path+".condition.any")
+	}
+}
+
+func (v *validator) checkWhenSteps(deps []codefresh.WhenStep, path string, group string) {
+	if len(deps) == 0 {
+		return
+	}
+	if _, ok := v.doc.positions[path+"."+group]; ok {
+		path = path + "." + group
+	}
+	for i, dep := range deps {
+		depPath := fmt.Sprintf("%s.%d", path, i)
+		if dep.Name == "" {
+			v.errorf(depPath, "step dependency has no name")
+			continue
+		}
+		if v.doc.Step(dep.Name) == nil {
+			v.errorf(depPath, "unknown step %q", dep.Name)
+		}
+		for _, state := range dep.On {
+			if !stepStates[state] {
+				v.errorf(depPath, "unknown step state %q", state)
+			}
+		}
+	}
+}
+
+func (v *validator) checkConditions(conditions map[string]string, path string) {
+	names := make([]string, 0, len(conditions))
+	for name := range conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := ParseCondition(conditions[name]); err != nil {
+			v.errorf(path+"."+name, "invalid condition: %v", err)
+		}
+	}
+}
+
+// checkInterpolations verifies the ${{VAR}} syntax of every string in the document
+func (v *validator) checkInterpolations() {
+	data, err := json.Marshal(v.doc.Spec)
+	if err != nil {
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return
+	}
+	v.walkStrings(value, "")
+}
+
+func (v *validator) walkStrings(value interface{}, path string) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for k, inner := range val {
+			v.walkStrings(inner, join(k))
+		}
+	case []interface{}:
+		for i, inner := range val {
+			v.walkStrings(inner, join(fmt.Sprint(i)))
+		}
+	case string:
+		for _, err := range CheckInterpolation(val) {
+			v.errorf(path, "%v", err)
+		}
+	}
+}
+
+// CheckInterpolation returns the syntax errors of the ${{VAR}} references in s
+func CheckInterpolation(s string) []error {
+	errs := []error{}
+	for {
+		start := strings.Index(s, "${{")
+		if start < 0 {
+			return errs
+		}
+		s = s[start+3:]
+		end := strings.Index(s, "}}")
+		if end < 0 {
+			return append(errs, fmt.Errorf("unterminated variable reference \"${{%s\"", s))
+		}
+		name := strings.TrimSpace(s[:end])
+		switch {
+		case name == "":
+			errs = append(errs, fmt.Errorf("empty variable reference \"${{}}\""))
+		case !variableName.MatchString(name):
+			errs = append(errs, fmt.Errorf("invalid variable name %q", name))
+		}
+		s = s[end+2:]
+	}
+}
+
+func stepType(step *codefresh.Step) string {
+	if step.Type == "" {
+		return codefresh.StepTypeFreestyle
+	}
+	return step.Type
+}
@@ -1,16 +1,26 @@
 package codefresh
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type (
 	ArgoAPI interface {
 		CreateIntegration(integration IntegrationPayloadData) error
+		CreateIntegrationWithContext(ctx context.Context, integration IntegrationPayloadData) error
 		UpdateIntegration(name string, integration IntegrationPayloadData) error
+		UpdateIntegrationWithContext(ctx context.Context, name string, integration IntegrationPayloadData) error
 		GetIntegrations() ([]*IntegrationPayload, error)
+		GetIntegrationsWithContext(ctx context.Context) ([]*IntegrationPayload, error)
 		GetIntegrationByName(name string) (*IntegrationPayload, error)
+		GetIntegrationByNameWithContext(ctx context.Context, name string) (*IntegrationPayload, error)
 		DeleteIntegrationByName(name string) error
+		DeleteIntegrationByNameWithContext(ctx context.Context, name string) error
 		HeartBeat(error string, version string, integration string) error
+		HeartBeatWithContext(ctx context.Context, error string, version string, integration string) error
 		SendResources(kind string, items interface{}, amount int, integration string) error
+		SendResourcesWithContext(ctx context.Context, kind string, items interface{}, amount int, integration string) error
 	}
 
 	argo struct {
@@ -54,9 +64,14 @@ func newArgoAPI(codefresh *codefresh) ArgoAPI {
 	return &argo{codefresh}
 }
 
+// Deprecated: use CreateIntegrationWithContext
 func (a *argo) CreateIntegration(integration IntegrationPayloadData) error {
+	return a.CreateIntegrationWithContext(context.Background(), integration)
+}
 
-	_, err := a.codefresh.requestAPI(&requestOptions{
+func (a *argo) CreateIntegrationWithContext(ctx context.Context, integration IntegrationPayloadData) error {
+
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/argo",
 		method: "POST",
 		body: &IntegrationPayload{
@@ -68,8 +83,13 @@ func (a *argo) CreateIntegration(integration IntegrationPayloadData) error {
 	return err
 }
 
+// Deprecated: use UpdateIntegrationWithContext
 func (a *argo) UpdateIntegration(name string, integration IntegrationPayloadData) error {
-	_, err := a.codefresh.requestAPI(&requestOptions{
+	return a.UpdateIntegrationWithContext(context.Background(), name, integration)
+}
+
+func (a *argo) UpdateIntegrationWithContext(ctx context.Context, name string, integration IntegrationPayloadData) error {
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "PUT",
 		path:   fmt.Sprintf("/api/argo/%s", name),
 		body: &IntegrationPayload{
@@ -84,10 +104,15 @@ func (a *argo) UpdateIntegration(name string, integration IntegrationPayloadData
 	return nil
 }
 
+// Deprecated: use GetIntegrationsWithContext
 func (a *argo) GetIntegrations() ([]*IntegrationPayload, error) {
+	return a.GetIntegrationsWithContext(context.Background())
+}
+
+func (a *argo) GetIntegrationsWithContext(ctx context.Context) ([]*IntegrationPayload, error) {
 	var result []*IntegrationPayload
 
-	resp, err := a.codefresh.requestAPI(&requestOptions{
+	resp, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "GET",
 		path:   "/api/argo",
 	})
@@ -105,10 +130,15 @@ func (a *argo) GetIntegrations() ([]*IntegrationPayload, error) {
 	return result, nil
 }
 
+// Deprecated: use GetIntegrationByNameWithContext
 func (a *argo) GetIntegrationByName(name string) (*IntegrationPayload, error) {
+	return a.GetIntegrationByNameWithContext(context.Background(), name)
+}
+
+func (a *argo) GetIntegrationByNameWithContext(ctx context.Context, name string) (*IntegrationPayload, error) {
 	var result IntegrationPayload
 
-	resp, err := a.codefresh.requestAPI(&requestOptions{
+	resp, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "GET",
 		path:   fmt.Sprintf("/api/argo/%s", name),
 	})
@@ -126,8 +156,13 @@ func (a *argo) GetIntegrationByName(name string) (*IntegrationPayload, error) {
 	return &result, nil
 }
 
+// Deprecated: use DeleteIntegrationByNameWithContext
 func (a *argo) DeleteIntegrationByName(name string) error {
-	_, err := a.codefresh.requestAPI(&requestOptions{
+	return a.DeleteIntegrationByNameWithContext(context.Background(), name)
+}
+
+func (a *argo) DeleteIntegrationByNameWithContext(ctx context.Context, name string) error {
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "DELETE",
 		path:   fmt.Sprintf("/api/argo/%s", name),
 	})
@@ -139,7 +174,12 @@ func (a *argo) DeleteIntegrationByName(name string) error {
 	return nil
 }
 
+// Deprecated: use HeartBeatWithContext
 func (a *argo) HeartBeat(error string, version string, integration string) error {
+	return a.HeartBeatWithContext(context.Background(), error, version, integration)
+}
+
+func (a *argo) HeartBeatWithContext(ctx context.Context, error string, version string, integration string) error {
 	var body = Heartbeat{}
 
 	if error != "" {
@@ -150,7 +190,7 @@ func (a *argo) HeartBeat(error string, version string, integration string) error
 		body.AgentVersion = version
 	}
 
-	_, err := a.codefresh.requestAPI(&requestOptions{
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "POST",
 		path:   fmt.Sprintf("/api/argo-agent/%s/heartbeat", integration),
 		body:   body,
@@ -162,12 +202,17 @@ func (a *argo) HeartBeat(error string, version string, integration string) error
 	return nil
 }
 
+// Deprecated: use SendResourcesWithContext
 func (a *argo) SendResources(kind string, items interface{}, amount int, integration string) error {
+	return a.SendResourcesWithContext(context.Background(), kind, items, amount, integration)
+}
+
+func (a *argo) SendResourcesWithContext(ctx context.Context, kind string, items interface{}, amount int, integration string) error {
 	if items == nil {
 		return nil
 	}
 
-	_, err := a.codefresh.requestAPI(&requestOptions{
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "POST",
 		path:   fmt.Sprintf("/api/argo-agent/%s", integration),
 		body:   &AgentState{Kind: kind, Items: items},
@@ -10,6 +10,7 @@ import (
 type (
 	IRuntimeAPI interface {
 		List(ctx context.Context) ([]model.Runtime, error)
+		ForEach(ctx context.Context, opt *PaginationOptions, fn func(*model.Runtime) error) error
 		Create(ctx context.Context, runtimeName, cluster, runtimeVersion string) (*model.RuntimeCreationResponse, error)
 	}
 
@@ -17,18 +18,22 @@ type (
 		codefresh *codefresh
 	}
 
+	graphqlRuntimesVariables struct {
+		Pagination model.SlicePaginationArgs `json:"pagination"`
+	}
+
 	graphqlRuntimesResponse struct {
-		Data struct {
-			Runtimes model.RuntimePage
-		}
-		Errors []graphqlError
+		Runtimes model.RuntimePage `json:"runtimes"`
+	}
+
+	graphqlRuntimeCreationVariables struct {
+		Name           string `json:"name"`
+		Cluster        string `json:"cluster"`
+		RuntimeVersion string `json:"runtimeVersion"`
 	}
 
 	graphQlRuntimeCreationResponse struct {
-		Data struct {
-			Runtime model.RuntimeCreationResponse
-		}
-		Errors []graphqlError
+		Runtime model.RuntimeCreationResponse `json:"runtime"`
 	}
 )
 
@@ -36,48 +41,65 @@ func newArgoRuntimeAPI(codefresh *codefresh) IRuntimeAPI {
 	return &argoRuntime{codefresh: codefresh}
 }
 
+// List - returns every runtime of the account, walking all pages
 func (r *argoRuntime) List(ctx context.Context) ([]model.Runtime, error) {
-	jsonData := map[string]interface{}{
-		"query": `{
-				runtimes {
-					edges {
-						node {
-							metadata {
-								name
-								namespace
-							}
-							self {
-								healthStatus
-								version
-							}
-							cluster
-						}
-					}
-				}
-			}`,
-	}
-
-	res := &graphqlRuntimesResponse{}
-	err := r.codefresh.graphqlAPI(ctx, jsonData, res)
+	runtimes := []model.Runtime{}
+	err := r.ForEach(ctx, nil, func(rt *model.Runtime) error {
+		runtimes = append(runtimes, *rt)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed getting runtime list: %w", err)
-	}
-
-	if len(res.Errors) > 0 {
-		return nil, graphqlErrorResponse{errors: res.Errors}
+		return nil, err
 	}
+	return runtimes, nil
+}
 
-	runtimes := make([]model.Runtime, len(res.Data.Runtimes.Edges))
-	for i := range res.Data.Runtimes.Edges {
-		runtimes[i] = *res.Data.Runtimes.Edges[i].Node
-	}
+// ForEach - calls fn for every runtime, fetching pages lazily
+func (r *argoRuntime) ForEach(ctx context.Context, opt *PaginationOptions, fn func(*model.Runtime) error) error {
+	return paginate(ctx, opt, func(ctx context.Context, args model.SlicePaginationArgs) (*cursorPage, error) {
+		res := &graphqlRuntimesResponse{}
+		err := r.codefresh.graphqlAPI(ctx, &GraphQLRequest{
+			OperationName: "Runtimes",
+			Query: `
+				query Runtimes($pagination: SlicePaginationArgs) {
+					runtimes(pagination: $pagination) {
+						edges {
+							node {
+								metadata {
+									name
+									namespace
+								}
+								self {
+									healthStatus
+									version
+								}
+								cluster
+							}
+						}
+						pageInfo {
+							endCursor
+							hasNextPage
+						}
+					}
+				}`,
+			Variables: &graphqlRuntimesVariables{Pagination: args},
+		}, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed getting runtime list: %w", err)
+		}
 
-	return runtimes, nil
+		edges := res.Runtimes.Edges
+		return newCursorPage(len(edges), res.Runtimes.PageInfo, func(i int) error {
+			return fn(edges[i].Node)
+		}), nil
+	})
 }
 
 func (r *argoRuntime) Create(ctx context.Context, runtimeName, cluster, runtimeVersion string) (*model.RuntimeCreationResponse, error) {
-	jsonData := map[string]interface{}{
-		"query": `
+	res := &graphQlRuntimeCreationResponse{}
+	err := r.codefresh.graphqlAPI(ctx, &GraphQLRequest{
+		OperationName: "CreateRuntime",
+		Query: `
 			mutation CreateRuntime(
 				$name: String!
 				$cluster: String!
@@ -89,22 +111,15 @@ func (r *argoRuntime) Create(ctx context.Context, runtimeName, cluster, runtimeV
 				}
 			}
 		`,
-		"variables": map[string]interface{}{
-			"name":           runtimeName,
-			"cluster":        cluster,
-			"runtimeVersion": runtimeVersion,
+		Variables: &graphqlRuntimeCreationVariables{
+			Name:           runtimeName,
+			Cluster:        cluster,
+			RuntimeVersion: runtimeVersion,
 		},
-	}
-
-	res := &graphQlRuntimeCreationResponse{}
-	err := r.codefresh.graphqlAPI(ctx, jsonData, res)
+	}, res)
 	if err != nil {
-		return nil, fmt.Errorf("failed getting runtime list: %w", err)
-	}
-
-	if len(res.Errors) > 0 {
-		return nil, graphqlErrorResponse{errors: res.Errors}
+		return nil, fmt.Errorf("failed creating runtime: %w", err)
 	}
 
-	return &res.Data.Runtime, nil
+	return &res.Runtime, nil
 }
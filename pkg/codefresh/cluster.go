@@ -1,11 +1,16 @@
 package codefresh
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type (
 	IClusterAPI interface {
 		GetClusterCredentialsByAccountId(selector string) (*Cluster, error)
+		GetClusterCredentialsByAccountIdWithContext(ctx context.Context, selector string) (*Cluster, error)
 		GetAccountClusters() ([]*ClusterMinified, error)
+		GetAccountClustersWithContext(ctx context.Context) ([]*ClusterMinified, error)
 	}
 
 	cluster struct {
@@ -35,22 +40,38 @@ func newClusterAPI(codefresh *codefresh) IClusterAPI {
 	return &cluster{codefresh}
 }
 
+// Deprecated: use GetClusterCredentialsByAccountIdWithContext
 func (p *cluster) GetClusterCredentialsByAccountId(selector string) (*Cluster, error) {
+	return p.GetClusterCredentialsByAccountIdWithContext(context.Background(), selector)
+}
+
+func (p *cluster) GetClusterCredentialsByAccountIdWithContext(ctx context.Context, selector string) (*Cluster, error) {
 	r := &Cluster{}
-	resp, err := p.codefresh.requestAPI(&requestOptions{
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   fmt.Sprintf("/api/clusters/%s/credentials", selector),
 		method: "GET",
 	})
+	if err != nil {
+		return nil, err
+	}
 	err = p.codefresh.decodeResponseInto(resp, &r)
 	return r, err
 }
 
+// Deprecated: use GetAccountClustersWithContext
 func (p *cluster) GetAccountClusters() ([]*ClusterMinified, error) {
+	return p.GetAccountClustersWithContext(context.Background())
+}
+
+func (p *cluster) GetAccountClustersWithContext(ctx context.Context) ([]*ClusterMinified, error) {
 	r := make([]*ClusterMinified, 0)
-	resp, err := p.codefresh.requestAPI(&requestOptions{
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   fmt.Sprintf("/api/clusters"),
 		method: "GET",
 	})
+	if err != nil {
+		return nil, err
+	}
 	err = p.codefresh.decodeResponseInto(resp, &r)
 	return r, err
 }
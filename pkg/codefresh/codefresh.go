@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -30,13 +29,17 @@ type (
 		Argo() ArgoAPI
 		Gitops() GitopsAPI
 		Projects() IProjectAPI
+		Triggers() ITriggerAPI
+		Logs() ILogsAPI
 		V2() V2API
+		RateLimiterStats() RateLimiterStats
 	}
 
 	V2API interface {
 		Runtime() IRuntimeAPI
 		GitSource() IGitSourceAPI
 		Component() IComponentAPI
+		GraphQL() IGraphQLAPI
 	}
 )
 
@@ -45,11 +48,22 @@ func New(opt *ClientOptions) Codefresh {
 	if opt.Client != nil {
 		httpClient = opt.Client
 	}
+	middlewares := opt.Middlewares
+	if opt.Debug {
+		logger := opt.Logger
+		if logger == nil {
+			logger = NewStderrLogger()
+		}
+		// innermost, so the log shows the request exactly as it is sent
+		middlewares = append(append([]Middleware{}, middlewares...), debugMiddleware(logger))
+	}
 
 	return &codefresh{
-		host:   opt.Host,
-		token:  opt.Auth.Token,
-		client: httpClient,
+		host:    opt.Host,
+		token:   opt.Auth.Token,
+		client:  withMiddlewares(httpClient, middlewares),
+		retry:   newRetryPolicy(opt.Retry),
+		limiter: newLimiter(opt.RateLimit),
 	}
 }
 
@@ -96,6 +110,14 @@ func (c *codefresh) Projects() IProjectAPI {
 	return newProjectAPI(c)
 }
 
+func (c *codefresh) Triggers() ITriggerAPI {
+	return newTriggerAPI(c)
+}
+
+func (c *codefresh) Logs() ILogsAPI {
+	return newLogsAPI(c)
+}
+
 func (c *codefresh) V2() V2API {
 	return c
 }
@@ -112,6 +134,14 @@ func (c *codefresh) Component() IComponentAPI {
 	return newComponentAPI(c)
 }
 
+func (c *codefresh) GraphQL() IGraphQLAPI {
+	return newGraphQLAPI(c)
+}
+
+func (c *codefresh) RateLimiterStats() RateLimiterStats {
+	return c.limiter.stats()
+}
+
 func (c *codefresh) requestAPI(opt *requestOptions) (*http.Response, error) {
 	return c.requestAPIWithContext(context.Background(), opt)
 }
@@ -125,7 +155,29 @@ func (c *codefresh) requestAPIWithContext(ctx context.Context, opt *requestOptio
 	if opt.body != nil {
 		body, _ = json.Marshal(opt.body)
 	}
-	request, err := http.NewRequestWithContext(ctx, opt.method, finalURL, bytes.NewBuffer(body))
+	attempts := c.retry.attempts(opt)
+	for attempt := 1; ; attempt++ {
+		response, err := c.doRequest(ctx, opt.method, finalURL, body)
+		if attempt < attempts && c.retry.shouldRetry(ctx, response, err) {
+			delay := c.retry.backoff(attempt, response)
+			discardResponse(response)
+			if err := sleepWithContext(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return response, err
+		}
+		if response.StatusCode >= http.StatusBadRequest {
+			return nil, newAPIError(response)
+		}
+		return response, nil
+	}
+}
+
+func (c *codefresh) doRequest(ctx context.Context, method string, url string, body []byte) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -133,35 +185,12 @@ func (c *codefresh) requestAPIWithContext(ctx context.Context, opt *requestOptio
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("origin", c.host)
 
-	response, err := c.client.Do(request)
+	release, err := c.limiter.acquire(ctx)
 	if err != nil {
-		return response, err
-	}
-	return response, nil
-}
-
-func (c *codefresh) graphqlAPI(ctx context.Context, body map[string]interface{}, res interface{}) error {
-	response, err := c.requestAPIWithContext(ctx, &requestOptions{
-		method: "POST",
-		path:   "/2.0/api/graphql",
-		body:   body,
-	})
-	if err != nil {
-		return fmt.Errorf("The HTTP request failed: %w", err)
-	}
-	defer response.Body.Close()
-
-	statusOK := response.StatusCode >= 200 && response.StatusCode < 300
-	if !statusOK {
-		return errors.New(response.Status)
-	}
-
-	data, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read from response body: %w", err)
+		return nil, err
 	}
-
-	return json.Unmarshal(data, res)
+	defer release()
+	return c.client.Do(request)
 }
 
 func buildQSFromMap(qs map[string]string) string {
@@ -188,6 +217,7 @@ func toQS(qs interface{}) string {
 }
 
 func (c *codefresh) decodeResponseInto(resp *http.Response, target interface{}) error {
+	defer resp.Body.Close()
 	return json.NewDecoder(resp.Body).Decode(target)
 }
 
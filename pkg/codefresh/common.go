@@ -1,40 +1,93 @@
 package codefresh
 
 import (
-	"fmt"
+	"net/http"
 	"strings"
+)
 
+// Error codes reported by the Codefresh GraphQL API in GraphQLError.Extensions.Code
+const (
+	GraphQLCodeUnauthenticated     = "UNAUTHENTICATED"
+	GraphQLCodeForbidden           = "FORBIDDEN"
+	GraphQLCodeNotFound            = "NOT_FOUND"
+	GraphQLCodeBadUserInput        = "BAD_USER_INPUT"
+	GraphQLCodeConflict            = "CONFLICT"
+	GraphQLCodeTooManyRequests     = "TOO_MANY_REQUESTS"
+	GraphQLCodeInternalServerError = "INTERNAL_SERVER_ERROR"
 )
 
-type graphqlError struct {
-	Message string
-	Locations [] struct {
-		Line int
-		Column int
+type (
+	// GraphQLError is a single entry of the "errors" array of a GraphQL response
+	GraphQLError struct {
+		Message    string                 `json:"message"`
+		Locations  []GraphQLErrorLocation `json:"locations"`
+		Path       []interface{}          `json:"path"`
+		Extensions GraphQLErrorExtensions `json:"extensions"`
+	}
+
+	GraphQLErrorLocation struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
 	}
-	Extensions struct {
-		Code string
+
+	GraphQLErrorExtensions struct {
+		Code      string `json:"code"`
 		Exception struct {
-			Stacktrace []string
-		}
+			Stacktrace []string `json:"stacktrace"`
+		} `json:"exception"`
 	}
+
+	// GraphQLErrors is returned by every GraphQL call that responds with errors,
+	// use errors.As with either *GraphQLErrors or **GraphQLError to inspect it
+	GraphQLErrors []*GraphQLError
+)
+
+var graphqlCodeToStatus = map[string]int{
+	GraphQLCodeUnauthenticated:     http.StatusUnauthorized,
+	GraphQLCodeForbidden:           http.StatusForbidden,
+	GraphQLCodeNotFound:            http.StatusNotFound,
+	GraphQLCodeBadUserInput:        http.StatusBadRequest,
+	GraphQLCodeConflict:            http.StatusConflict,
+	GraphQLCodeTooManyRequests:     http.StatusTooManyRequests,
+	GraphQLCodeInternalServerError: http.StatusInternalServerError,
 }
 
-type graphqlErrorResponse struct {
-	errors []graphqlError 
-	concatenatedErrors string
+func (e *GraphQLError) Error() string {
+	if e.Extensions.Code == "" {
+		return e.Message
+	}
+	return e.Message + " (code: " + e.Extensions.Code + ")"
 }
-	
 
-func (e graphqlErrorResponse) Error() string {
+// StatusCode returns the HTTP status equivalent of the extension code, 0 when unknown
+func (e *GraphQLError) StatusCode() int {
+	return graphqlCodeToStatus[e.Extensions.Code]
+}
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
 
-	if e.concatenatedErrors != "" {
-		return e.concatenatedErrors
+// As lets errors.As extract the first *GraphQLError
+func (e GraphQLErrors) As(target interface{}) bool {
+	t, ok := target.(**GraphQLError)
+	if !ok || len(e) == 0 {
+		return false
 	}
-	var sb strings.Builder
-	for _, err := range e.errors {
-		sb.WriteString(fmt.Sprintln(err.Message))
+	*t = e[0]
+	return true
+}
+
+// hasStatus reports whether any of the errors maps to the given HTTP status
+func (e GraphQLErrors) hasStatus(status int) bool {
+	for _, err := range e {
+		if err.StatusCode() == status {
+			return true
+		}
 	}
-	e.concatenatedErrors = sb.String()
-	return e.concatenatedErrors
-}
\ No newline at end of file
+	return false
+}
@@ -10,17 +10,20 @@ import (
 type (
 	IComponentAPI interface {
 		List(ctx context.Context, runtimeName string) ([]model.Component, error)
+		ForEach(ctx context.Context, runtimeName string, opt *PaginationOptions, fn func(*model.Component) error) error
 	}
 
 	component struct {
 		codefresh *codefresh
 	}
 
+	graphqlComponentsVariables struct {
+		Runtime    string                    `json:"runtime"`
+		Pagination model.SlicePaginationArgs `json:"pagination"`
+	}
+
 	graphqlComponentsResponse struct {
-		Data struct {
-			Components model.ComponentPage
-		}
-		Errors []graphqlError
+		Components model.ComponentPage `json:"components"`
 	}
 )
 
@@ -28,46 +31,60 @@ func newComponentAPI(codefresh *codefresh) IComponentAPI {
 	return &component{codefresh: codefresh}
 }
 
+// List - returns every component of the runtime, walking all pages
 func (r *component) List(ctx context.Context, runtimeName string) ([]model.Component, error) {
-	jsonData := map[string]interface{}{
-		"query": `
-			query Components($runtime: String!) {
-				components(runtime: $runtime) {
-					edges {
-						node {
-							metadata {
-								name
-							}
-							version
-							self {
-								status {
-									syncStatus
-									healthStatus
+	components := []model.Component{}
+	err := r.ForEach(ctx, runtimeName, nil, func(c *model.Component) error {
+		components = append(components, *c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// ForEach - calls fn for every component of the runtime, fetching pages lazily
+func (r *component) ForEach(ctx context.Context, runtimeName string, opt *PaginationOptions, fn func(*model.Component) error) error {
+	return paginate(ctx, opt, func(ctx context.Context, args model.SlicePaginationArgs) (*cursorPage, error) {
+		res := &graphqlComponentsResponse{}
+		err := r.codefresh.graphqlAPI(ctx, &GraphQLRequest{
+			OperationName: "Components",
+			Query: `
+				query Components($runtime: String!, $pagination: SlicePaginationArgs) {
+					components(runtime: $runtime, pagination: $pagination) {
+						edges {
+							node {
+								metadata {
+									name
+								}
+								version
+								self {
+									status {
+										syncStatus
+										healthStatus
+									}
 								}
 							}
 						}
+						pageInfo {
+							endCursor
+							hasNextPage
+						}
 					}
-				}
-			}`,
-		"variables": map[string]interface{}{
-			"runtime": runtimeName,
-		},
-	}
-
-	res := &graphqlComponentsResponse{}
-	err := r.codefresh.graphqlAPI(ctx, jsonData, res)
-	if err != nil {
-		return nil, fmt.Errorf("failed getting components list: %w", err)
-	}
-
-	if len(res.Errors) > 0 {
-		return nil, graphqlErrorResponse{errors: res.Errors}
-	}
-
-	components := make([]model.Component, len(res.Data.Components.Edges))
-	for i := range res.Data.Components.Edges {
-		components[i] = *res.Data.Components.Edges[i].Node
-	}
+				}`,
+			Variables: &graphqlComponentsVariables{
+				Runtime:    runtimeName,
+				Pagination: args,
+			},
+		}, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed getting components list: %w", err)
+		}
 
-	return components, nil
+		edges := res.Components.Edges
+		return newCursorPage(len(edges), res.Components.PageInfo, func(i int) error {
+			return fn(edges[i].Node)
+		}), nil
+	})
 }
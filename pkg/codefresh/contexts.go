@@ -1,10 +1,15 @@
 package codefresh
 
+import "context"
+
 type (
 	IContextAPI interface {
 		GetGitContexts() (error, *[]ContextPayload)
+		GetGitContextsWithContext(ctx context.Context) (error, *[]ContextPayload)
 		GetGitContextByName(name string) (error, *ContextPayload)
+		GetGitContextByNameWithContext(ctx context.Context, name string) (error, *ContextPayload)
 		GetDefaultGitContext() (error, *ContextPayload)
+		GetDefaultGitContextWithContext(ctx context.Context) (error, *ContextPayload)
 	}
 
 	contexts struct {
@@ -45,7 +50,12 @@ func newContextAPI(codefresh *codefresh) IContextAPI {
 	return &contexts{codefresh}
 }
 
+// Deprecated: use GetGitContextsWithContext
 func (c contexts) GetGitContexts() (error, *[]ContextPayload) {
+	return c.GetGitContextsWithContext(context.Background())
+}
+
+func (c contexts) GetGitContextsWithContext(ctx context.Context) (error, *[]ContextPayload) {
 	var result []ContextPayload
 
 	qs := GitContextsQs{
@@ -53,7 +63,7 @@ func (c contexts) GetGitContexts() (error, *[]ContextPayload) {
 		Decrypt: "true",
 	}
 
-	resp, err := c.codefresh.requestAPI(&requestOptions{
+	resp, err := c.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "GET",
 		path:   "/api/contexts",
 		qs:     qs,
@@ -67,13 +77,18 @@ func (c contexts) GetGitContexts() (error, *[]ContextPayload) {
 	return err, &result
 }
 
+// Deprecated: use GetGitContextByNameWithContext
 func (c contexts) GetGitContextByName(name string) (error, *ContextPayload) {
+	return c.GetGitContextByNameWithContext(context.Background(), name)
+}
+
+func (c contexts) GetGitContextByNameWithContext(ctx context.Context, name string) (error, *ContextPayload) {
 	var result ContextPayload
 	var qs = map[string]string{
 		"decrypt": "true",
 	}
 
-	resp, err := c.codefresh.requestAPI(&requestOptions{
+	resp, err := c.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "GET",
 		path:   "/api/contexts/" + name,
 		qs:     qs,
@@ -84,13 +99,18 @@ func (c contexts) GetGitContextByName(name string) (error, *ContextPayload) {
 
 	err = c.codefresh.decodeResponseInto(resp, &result)
 
-	return nil, &result
+	return err, &result
 }
 
+// Deprecated: use GetDefaultGitContextWithContext
 func (c contexts) GetDefaultGitContext() (error, *ContextPayload) {
+	return c.GetDefaultGitContextWithContext(context.Background())
+}
+
+func (c contexts) GetDefaultGitContextWithContext(ctx context.Context) (error, *ContextPayload) {
 	var result ContextPayload
 
-	resp, err := c.codefresh.requestAPI(&requestOptions{
+	resp, err := c.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "GET",
 		path:   "/api/contexts/git/default",
 	})
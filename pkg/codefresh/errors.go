@@ -0,0 +1,149 @@
+package codefresh
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type (
+	// APIError is returned by every REST call when Codefresh responds with a non 2xx/3xx status
+	APIError struct {
+		// StatusCode - the HTTP status code of the response
+		StatusCode int
+		// Code - Codefresh error code, when provided by the API
+		Code string
+		// Message - human readable error message
+		Message string
+		// RequestID - value of the X-Request-Id response header, useful when contacting support
+		RequestID string
+		// Method - HTTP method of the failed request
+		Method string
+		// URL - full URL of the failed request
+		URL string
+		// Body - raw response body
+		Body []byte
+	}
+
+	// WorkflowStateError is returned by build operations that are not allowed in the current build status
+	WorkflowStateError struct {
+		ID        string
+		Operation string
+		Status    string
+		// Allowed - statuses in which the operation is possible
+		Allowed []string
+	}
+
+	apiErrorBody struct {
+		Code      json.RawMessage `json:"code"`
+		Name      string          `json:"name"`
+		Message   string          `json:"message"`
+		Error     string          `json:"error"`
+		RequestID string          `json:"requestId"`
+	}
+)
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s (code: %s)", msg, e.Code)
+	}
+	return fmt.Sprintf("%s %s: %d: %s", e.Method, e.URL, e.StatusCode, msg)
+}
+
+func (e *WorkflowStateError) Error() string {
+	return fmt.Sprintf("cannot %s build %s in status %s, expected one of: %s", e.Operation, e.ID, e.Status, strings.Join(e.Allowed, ", "))
+}
+
+// IsInvalidState returns true when err is a *WorkflowStateError
+func IsInvalidState(err error) bool {
+	var stateErr *WorkflowStateError
+	return errors.As(err, &stateErr)
+}
+
+// IsNotFound returns true when err is an *APIError with status 404, or GraphQLErrors with the equivalent code
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized returns true when err is an *APIError with status 401 or 403, or GraphQLErrors with the equivalent code
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized) || hasStatus(err, http.StatusForbidden)
+}
+
+// IsConflict returns true when err is an *APIError with status 409, or GraphQLErrors with the equivalent code
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsRateLimited returns true when err is an *APIError with status 429, or GraphQLErrors with the equivalent code
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == status
+	}
+	var gqlErrs GraphQLErrors
+	if errors.As(err, &gqlErrs) {
+		return gqlErrs.hasStatus(status)
+	}
+	return false
+}
+
+// newAPIError consumes and closes the response body
+func newAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.String()
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return e
+	}
+	e.Body = body
+
+	parsed := &apiErrorBody{}
+	if err := json.Unmarshal(body, parsed); err != nil {
+		e.Message = strings.TrimSpace(string(body))
+		return e
+	}
+	e.Code = rawToString(parsed.Code)
+	switch {
+	case parsed.Message != "":
+		e.Message = parsed.Message
+	case parsed.Error != "":
+		e.Message = parsed.Error
+	default:
+		e.Message = parsed.Name
+	}
+	if e.RequestID == "" {
+		e.RequestID = parsed.RequestID
+	}
+	return e
+}
+
+// rawToString accepts both `"code": "1001"` and `"code": 1001`
+func rawToString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return strings.TrimSpace(string(raw))
+}
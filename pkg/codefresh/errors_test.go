@@ -0,0 +1,46 @@
+package codefresh
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":404,"code":"1001","name":"NOT_FOUND","message":"Runtime environment not found"}`))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	_, err := cf.RuntimeEnvironments().Get("missing")
+
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsConflict(err))
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "1001", apiErr.Code)
+	assert.Equal(t, "Runtime environment not found", apiErr.Message)
+	assert.Equal(t, "req-1", apiErr.RequestID)
+}
+
+func TestAPIErrorPlainBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("slow down"))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	_, err := cf.Pipelines().List(nil)
+
+	assert.True(t, IsRateLimited(err))
+	assert.Contains(t, err.Error(), "slow down")
+}
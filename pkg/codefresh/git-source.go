@@ -10,17 +10,20 @@ import (
 type (
 	IGitSourceAPI interface {
 		List(ctc context.Context, runtimeName string) ([]model.GitSource, error)
+		ForEach(ctx context.Context, runtimeName string, opt *PaginationOptions, fn func(*model.GitSource) error) error
 	}
 
 	gitSource struct {
 		codefresh *codefresh
 	}
 
+	graphQlGitSourcesListVariables struct {
+		Runtime    string                    `json:"runtime"`
+		Pagination model.SlicePaginationArgs `json:"pagination"`
+	}
+
 	graphQlGitSourcesListResponse struct {
-		Data struct {
-			GitSources model.GitSourcePage
-		}
-		Errors []graphqlError
+		GitSources model.GitSourcePage `json:"gitSources"`
 	}
 )
 
@@ -28,47 +31,61 @@ func newGitSourceAPI(codefresh *codefresh) IGitSourceAPI {
 	return &gitSource{codefresh: codefresh}
 }
 
+// List - returns every git-source of the runtime, walking all pages
 func (g *gitSource) List(ctx context.Context, runtimeName string) ([]model.GitSource, error) {
-	jsonData := map[string]interface{}{
-		"query": `
-			query GitSources($runtime: String) {
-				gitSources(runtime: $runtime) {
-					edges {
-						node {
-							metadata {
-								name
-							}
-							self {
-								path
-								repoURL
-								status {
-									syncStatus
-									healthStatus
+	gitSources := []model.GitSource{}
+	err := g.ForEach(ctx, runtimeName, nil, func(gs *model.GitSource) error {
+		gitSources = append(gitSources, *gs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gitSources, nil
+}
+
+// ForEach - calls fn for every git-source of the runtime, fetching pages lazily
+func (g *gitSource) ForEach(ctx context.Context, runtimeName string, opt *PaginationOptions, fn func(*model.GitSource) error) error {
+	return paginate(ctx, opt, func(ctx context.Context, args model.SlicePaginationArgs) (*cursorPage, error) {
+		res := &graphQlGitSourcesListResponse{}
+		err := g.codefresh.graphqlAPI(ctx, &GraphQLRequest{
+			OperationName: "GitSources",
+			Query: `
+				query GitSources($runtime: String, $pagination: SlicePaginationArgs) {
+					gitSources(runtime: $runtime, pagination: $pagination) {
+						edges {
+							node {
+								metadata {
+									name
+								}
+								self {
+									path
+									repoURL
+									status {
+										syncStatus
+										healthStatus
+									}
 								}
 							}
 						}
+						pageInfo {
+							endCursor
+							hasNextPage
+						}
 					}
-				}
-			}`,
-		"variables": map[string]interface{}{
-			"runtime": runtimeName,
-		},
-	}
-
-	res := &graphQlGitSourcesListResponse{}
-	err := g.codefresh.graphqlAPI(ctx, jsonData, res)
-	if err != nil {
-		return nil, fmt.Errorf("failed getting git-source list: %w", err)
-	}
-
-	gitSources := make([]model.GitSource, len(res.Data.GitSources.Edges))
-	for i := range res.Data.GitSources.Edges {
-		gitSources[i] = *res.Data.GitSources.Edges[i].Node
-	}
-
-	if len(res.Errors) > 0 {
-		return nil, graphqlErrorResponse{errors: res.Errors}
-	}
+				}`,
+			Variables: &graphQlGitSourcesListVariables{
+				Runtime:    runtimeName,
+				Pagination: args,
+			},
+		}, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed getting git-source list: %w", err)
+		}
 
-	return gitSources, nil
+		edges := res.GitSources.Edges
+		return newCursorPage(len(edges), res.GitSources.PageInfo, func(i int) error {
+			return fn(edges[i].Node)
+		}), nil
+	})
 }
@@ -1,17 +1,24 @@
 package codefresh
 
 import (
+	"context"
 	"fmt"
 )
 
 type (
 	GitopsAPI interface {
 		CreateEnvironment(name string, project string, application string, integration string) error
+		CreateEnvironmentWithContext(ctx context.Context, name string, project string, application string, integration string) error
 		SendEnvironment(environment Environment) (map[string]interface{}, error)
+		SendEnvironmentWithContext(ctx context.Context, environment Environment) (map[string]interface{}, error)
 		DeleteEnvironment(name string) error
+		DeleteEnvironmentWithContext(ctx context.Context, name string) error
 		GetEnvironments() ([]CFEnvironment, error)
+		GetEnvironmentsWithContext(ctx context.Context) ([]CFEnvironment, error)
 		SendEvent(name string, props map[string]string) error
+		SendEventWithContext(ctx context.Context, name string, props map[string]string) error
 		SendApplicationResources(resources *ApplicationResources) error
+		SendApplicationResourcesWithContext(ctx context.Context, resources *ApplicationResources) error
 	}
 
 	gitops struct {
@@ -129,8 +136,13 @@ func newGitopsAPI(codefresh *codefresh) GitopsAPI {
 	return &gitops{codefresh}
 }
 
+// Deprecated: use CreateEnvironmentWithContext
 func (a *gitops) CreateEnvironment(name string, project string, application string, integration string) error {
-	_, err := a.codefresh.requestAPI(&requestOptions{
+	return a.CreateEnvironmentWithContext(context.Background(), name, project, application, integration)
+}
+
+func (a *gitops) CreateEnvironmentWithContext(ctx context.Context, name string, project string, application string, integration string) error {
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "POST",
 		path:   "/api/environments-v2",
 		body: &EnvironmentPayload{
@@ -153,9 +165,14 @@ func (a *gitops) CreateEnvironment(name string, project string, application stri
 	return nil
 }
 
+// Deprecated: use SendEnvironmentWithContext
 func (a *gitops) SendEnvironment(environment Environment) (map[string]interface{}, error) {
+	return a.SendEnvironmentWithContext(context.Background(), environment)
+}
+
+func (a *gitops) SendEnvironmentWithContext(ctx context.Context, environment Environment) (map[string]interface{}, error) {
 	var result map[string]interface{}
-	resp, err := a.codefresh.requestAPI(&requestOptions{method: "POST", path: "/api/environments-v2/argo/events", body: environment})
+	resp, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{method: "POST", path: "/api/environments-v2/argo/events", body: environment})
 	if err != nil {
 		return nil, err
 	}
@@ -169,8 +186,13 @@ func (a *gitops) SendEnvironment(environment Environment) (map[string]interface{
 	return result, nil
 }
 
+// Deprecated: use DeleteEnvironmentWithContext
 func (a *gitops) DeleteEnvironment(name string) error {
-	_, err := a.codefresh.requestAPI(&requestOptions{
+	return a.DeleteEnvironmentWithContext(context.Background(), name)
+}
+
+func (a *gitops) DeleteEnvironmentWithContext(ctx context.Context, name string) error {
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "DELETE",
 		path:   fmt.Sprintf("/api/environments-v2/%s", name),
 	})
@@ -181,9 +203,14 @@ func (a *gitops) DeleteEnvironment(name string) error {
 	return nil
 }
 
+// Deprecated: use GetEnvironmentsWithContext
 func (a *gitops) GetEnvironments() ([]CFEnvironment, error) {
+	return a.GetEnvironmentsWithContext(context.Background())
+}
+
+func (a *gitops) GetEnvironmentsWithContext(ctx context.Context) ([]CFEnvironment, error) {
 	var result MongoCFEnvWrapper
-	resp, err := a.codefresh.requestAPI(&requestOptions{
+	resp, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "GET",
 		path:   "/api/environments-v2?plain=true&isEnvironment=false",
 	})
@@ -200,10 +227,15 @@ func (a *gitops) GetEnvironments() ([]CFEnvironment, error) {
 	return result.Docs, nil
 }
 
+// Deprecated: use SendEventWithContext
 func (a *gitops) SendEvent(name string, props map[string]string) error {
+	return a.SendEventWithContext(context.Background(), name, props)
+}
+
+func (a *gitops) SendEventWithContext(ctx context.Context, name string, props map[string]string) error {
 	event := CodefreshEvent{Event: name, Props: props}
 
-	_, err := a.codefresh.requestAPI(&requestOptions{
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "POST",
 		path:   "/api/gitops/system/events",
 		body:   event,
@@ -215,8 +247,13 @@ func (a *gitops) SendEvent(name string, props map[string]string) error {
 	return nil
 }
 
+// Deprecated: use SendApplicationResourcesWithContext
 func (a *gitops) SendApplicationResources(resources *ApplicationResources) error {
-	_, err := a.codefresh.requestAPI(&requestOptions{
+	return a.SendApplicationResourcesWithContext(context.Background(), resources)
+}
+
+func (a *gitops) SendApplicationResourcesWithContext(ctx context.Context, resources *ApplicationResources) error {
+	_, err := a.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		method: "POST",
 		path:   fmt.Sprintf("/api/gitops/resources"),
 		body:   &resources,
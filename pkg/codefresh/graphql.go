@@ -0,0 +1,203 @@
+package codefresh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+type (
+	// IGraphQLAPI executes arbitrary operations against the Codefresh GraphQL API
+	IGraphQLAPI interface {
+		Do(ctx context.Context, req *GraphQLRequest, result interface{}) error
+	}
+
+	// GraphQLRequest
+	GraphQLRequest struct {
+
+		// OperationName - selects the operation to run when Query holds more than one
+		OperationName string
+
+		// Query - the query or mutation document
+		Query string
+
+		// Variables - a struct or a map, marshaled to JSON
+		Variables interface{}
+
+		// AllowPartialData - decode data into the result even when the response carries errors,
+		// the errors are still returned
+		AllowPartialData bool
+	}
+
+	graphql struct {
+		codefresh *codefresh
+	}
+
+	graphqlRequestBody struct {
+		Query         string      `json:"query"`
+		OperationName string      `json:"operationName,omitempty"`
+		Variables     interface{} `json:"variables,omitempty"`
+	}
+
+	graphqlResponseBody struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+)
+
+func newGraphQLAPI(codefresh *codefresh) IGraphQLAPI {
+	return &graphql{codefresh: codefresh}
+}
+
+// Do - executes the request and decodes the "data" object of the response into result
+func (g *graphql) Do(ctx context.Context, req *GraphQLRequest, result interface{}) error {
+	return g.codefresh.graphqlAPI(ctx, req, result)
+}
+
+func (c *codefresh) graphqlAPI(ctx context.Context, req *GraphQLRequest, result interface{}) error {
+	response, err := c.requestAPIWithContext(ctx, &requestOptions{
+		method: "POST",
+		path:   "/2.0/api/graphql",
+		body: &graphqlRequestBody{
+			Query:         req.Query,
+			OperationName: req.OperationName,
+			Variables:     req.Variables,
+		},
+		// queries are safe to retry, mutations follow RetryOptions.RetryNonIdempotent
+		idempotent: isGraphqlQuery(req.Query, req.OperationName),
+	})
+	if err != nil {
+		// Apollo answers failed operations with a 4xx/5xx status and the errors in the usual body
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			res := &graphqlResponseBody{}
+			if json.Unmarshal(apiErr.Body, res) == nil && len(res.Errors) > 0 {
+				return res.Errors
+			}
+		}
+		return fmt.Errorf("The HTTP request failed: %w", err)
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read from response body: %w", err)
+	}
+
+	res := &graphqlResponseBody{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return fmt.Errorf("failed to decode graphql response: %w", err)
+	}
+
+	if len(res.Errors) > 0 && !req.AllowPartialData {
+		return res.Errors
+	}
+
+	if result != nil && len(res.Data) > 0 && string(res.Data) != "null" {
+		if err := json.Unmarshal(res.Data, result); err != nil {
+			return fmt.Errorf("failed to decode graphql data: %w", err)
+		}
+	}
+
+	if len(res.Errors) > 0 {
+		return res.Errors
+	}
+	return nil
+}
+
+// isGraphqlQuery reports whether the operation selected by operationName is a query,
+// anything it cannot tell for sure is treated as a mutation so it is not retried
+func isGraphqlQuery(document string, operationName string) bool {
+	var ops []graphqlOperation
+	for _, op := range graphqlDefinitions(document) {
+		if op.kind != "fragment" {
+			ops = append(ops, op)
+		}
+	}
+	for _, op := range ops {
+		if operationName == "" && len(ops) == 1 || operationName != "" && op.name == operationName {
+			return op.kind == "query"
+		}
+	}
+	return false
+}
+
+type graphqlOperation struct {
+	kind string
+	name string
+}
+
+// graphqlDefinitions lists the top level definitions of a document with their keyword and name,
+// skipping comments and strings. The "{ ... }" shorthand is listed as an anonymous query
+func graphqlDefinitions(document string) []graphqlOperation {
+	var ops []graphqlOperation
+	var current *graphqlOperation
+	depth := 0
+	for i := 0; i < len(document); i++ {
+		c := document[i]
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			i = skipGraphqlString(document, i)
+		case c == '@':
+			for i+1 < len(document) && isGraphqlNameChar(document[i+1]) {
+				i++
+			}
+		case c == '{' || c == '(' || c == '[':
+			if depth == 0 && c == '{' && current == nil {
+				ops = append(ops, graphqlOperation{kind: "query"})
+			}
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			if depth == 0 && c == '}' {
+				current = nil
+			}
+		case depth == 0 && isGraphqlNameStart(c):
+			start := i
+			for i+1 < len(document) && isGraphqlNameChar(document[i+1]) {
+				i++
+			}
+			word := document[start : i+1]
+			switch {
+			case current == nil:
+				ops = append(ops, graphqlOperation{kind: word})
+				current = &ops[len(ops)-1]
+			case current.name == "":
+				current.name = word
+			}
+		}
+	}
+	return ops
+}
+
+// skipGraphqlString returns the index of the closing quote of the string or block string starting at i
+func skipGraphqlString(document string, i int) int {
+	if strings.HasPrefix(document[i:], `"""`) {
+		end := strings.Index(document[i+3:], `"""`)
+		if end < 0 {
+			return len(document)
+		}
+		return i + 3 + end + 2
+	}
+	for i++; i < len(document) && document[i] != '"' && document[i] != '\n'; i++ {
+		if document[i] == '\\' {
+			i++
+		}
+	}
+	return i
+}
+
+func isGraphqlNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isGraphqlNameChar(c byte) bool {
+	return isGraphqlNameStart(c) || c >= '0' && c <= '9'
+}
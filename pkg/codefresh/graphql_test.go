@@ -0,0 +1,137 @@
+package codefresh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func graphqlServer(t *testing.T, response string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "Me", body["operationName"])
+		assert.Equal(t, map[string]interface{}{"id": "1"}, body["variables"])
+		w.Write([]byte(response))
+	}))
+}
+
+func TestGraphQLPartialData(t *testing.T) {
+	server := graphqlServer(t, `{"data":{"me":{"name":"john"}},"errors":[{"message":"field failed","extensions":{"code":"INTERNAL_SERVER_ERROR"}}]}`)
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	req := &GraphQLRequest{
+		OperationName: "Me",
+		Query:         `query Me($id: ID!) { me(id: $id) { name } }`,
+		Variables:     map[string]string{"id": "1"},
+	}
+	res := struct {
+		Me struct {
+			Name string `json:"name"`
+		} `json:"me"`
+	}{}
+
+	err := cf.V2().GraphQL().Do(context.Background(), req, &res)
+	assert.Error(t, err)
+	assert.Equal(t, "", res.Me.Name)
+
+	req.AllowPartialData = true
+	err = cf.V2().GraphQL().Do(context.Background(), req, &res)
+	assert.Error(t, err)
+	assert.Equal(t, "john", res.Me.Name)
+}
+
+func TestGraphQLErrorsMatchSentinels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":null,"errors":[{"message":"runtime not found","locations":[{"line":2,"column":5}],"extensions":{"code":"NOT_FOUND"}}]}`))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	_, err := cf.V2().Runtime().List(context.Background())
+
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsUnauthorized(err))
+
+	var gqlErr *GraphQLError
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("expected *GraphQLError, got %T", err)
+	}
+	assert.Equal(t, GraphQLCodeNotFound, gqlErr.Extensions.Code)
+	assert.Equal(t, 2, gqlErr.Locations[0].Line)
+}
+
+func TestIsGraphqlQuery(t *testing.T) {
+	tests := map[string]struct {
+		query         string
+		operationName string
+		want          bool
+	}{
+		"query":             {query: `query Me { me { name } }`, want: true},
+		"shorthand":         {query: `{ me { name } }`, want: true},
+		"mutation":          {query: `mutation { deleteRuntime(name: "r") }`},
+		"subscription":      {query: `subscription { events { id } }`},
+		"comment first":     {query: "# deletes the runtime\nmutation { deleteRuntime(name: \"r\") }"},
+		"commented out":     {query: "# query Old { me { name } }\nmutation Del { deleteRuntime(name: \"r\") }"},
+		"fragment first":    {query: "fragment F on Runtime { name }\nmutation Del { deleteRuntime(name: \"r\") { ...F } }"},
+		"fragment query":    {query: "fragment F on Runtime { name }\nquery R { runtime(name: \"r\") { ...F } }", want: true},
+		"string braces":     {query: `mutation Del($n: String = "} query {") { deleteRuntime(name: $n) }`},
+		"directive":         {query: `query @cached { me { name } }`, want: true},
+		"selected query":    {query: "mutation Del { deleteRuntime(name: \"r\") }\nquery Get { runtime(name: \"r\") { name } }", operationName: "Get", want: true},
+		"selected mutation": {query: "query Get { runtime(name: \"r\") { name } }\nmutation Del { deleteRuntime(name: \"r\") }", operationName: "Del"},
+		"ambiguous":         {query: "query Get { runtime(name: \"r\") { name } }\nmutation Del { deleteRuntime(name: \"r\") }"},
+		"unknown name":      {query: `query Get { me { name } }`, operationName: "Other"},
+		"empty":             {query: ``},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isGraphqlQuery(tt.query, tt.operationName))
+		})
+	}
+}
+
+func TestGraphQLErrorsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":[{"message":"user is not authorized","extensions":{"code":"FORBIDDEN"}}]}`))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	_, err := cf.V2().Runtime().List(context.Background())
+
+	assert.True(t, IsUnauthorized(err))
+	var gqlErr *GraphQLError
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("expected *GraphQLError, got %T", err)
+	}
+	assert.Equal(t, "user is not authorized", gqlErr.Message)
+	assert.Equal(t, GraphQLCodeForbidden, gqlErr.Extensions.Code)
+}
+
+func TestGraphQLErrorStatusWithoutErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"no such route"}`))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	_, err := cf.V2().Runtime().List(context.Background())
+
+	assert.True(t, IsNotFound(err))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	assert.Equal(t, "no such route", apiErr.Message)
+}
@@ -0,0 +1,259 @@
+package codefresh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redacted      = "REDACTED"
+	maxLoggedBody = 4096
+	// bodies are parsed whole to be redacted, larger ones are not read for logging
+	maxPeekedBody   = 1 << 20
+	truncatedSuffix = "...(truncated)"
+)
+
+type (
+	// Logger is a minimal structured logger, keysAndValues are alternating key/value pairs
+	Logger interface {
+		Debug(msg string, keysAndValues ...interface{})
+	}
+
+	// stderrLogger is the default Logger, writes logfmt-like lines to stderr
+	stderrLogger struct {
+		mu  sync.Mutex
+		out io.Writer
+	}
+)
+
+var (
+	redactedHeaders = map[string]bool{
+		"authorization":  true,
+		"cookie":         true,
+		"set-cookie":     true,
+		"x-access-token": true,
+	}
+
+	// matched case-insensitively against JSON object keys
+	redactedFields = []string{
+		"password",
+		"token",
+		"secret",
+		"privatekey",
+		"apikey",
+		"authorization",
+		"csr",
+	}
+)
+
+// NewStderrLogger returns the Logger used when ClientOptions.Debug is set without a Logger
+func NewStderrLogger() Logger {
+	return &stderrLogger{out: os.Stderr}
+}
+
+func (l *stderrLogger) Debug(msg string, keysAndValues ...interface{}) {
+	var sb strings.Builder
+	sb.WriteString(time.Now().Format(time.RFC3339))
+	sb.WriteString(" DEBUG ")
+	sb.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var value interface{} = "<missing>"
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		sb.WriteString(fmt.Sprintf(" %v=%q", keysAndValues[i], fmt.Sprint(value)))
+	}
+	sb.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.out, sb.String())
+}
+
+// debugMiddleware logs every request and response sent on the wire
+func debugMiddleware(logger Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqBody := peekRequestBody(req)
+			logger.Debug("codefresh request",
+				"method", req.Method,
+				"url", redactURL(req.URL),
+				"headers", redactHeaders(req.Header),
+				"body", redactBody(reqBody),
+			)
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			latency := time.Since(start)
+			if err != nil {
+				// the *url.Error message repeats the url with its query
+				logErr := err
+				if urlErr, ok := err.(*url.Error); ok {
+					logErr = urlErr.Err
+				}
+				logger.Debug("codefresh request failed",
+					"method", req.Method,
+					"url", redactURL(req.URL),
+					"latency", latency,
+					"error", logErr,
+				)
+				return resp, err
+			}
+
+			logger.Debug("codefresh response",
+				"method", req.Method,
+				"url", redactURL(req.URL),
+				"status", resp.StatusCode,
+				"latency", latency,
+				"headers", redactHeaders(resp.Header),
+				"body", peekResponseBody(resp),
+			)
+			return resp, nil
+		})
+	}
+}
+
+// peekRequestBody reads the body and puts it back so the request can still be sent
+func peekRequestBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// peekResponseBody reads up to maxPeekedBody bytes of a JSON body and puts them back in front
+// of the rest for the caller to decode, other bodies (e.g. logs and artifacts) are left untouched
+func peekResponseBody(resp *http.Response) string {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return ""
+	}
+	if !isJSONContent(resp.Header.Get("Content-Type")) {
+		if resp.ContentLength > 0 {
+			return fmt.Sprintf("<%d bytes>", resp.ContentLength)
+		}
+		return ""
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPeekedBody+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil {
+		return ""
+	}
+	if len(body) > maxPeekedBody {
+		return fmt.Sprintf("<more than %d bytes>", maxPeekedBody)
+	}
+	return redactBody(body)
+}
+
+func isJSONContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// redactURL masks the query values, presigned URLs (e.g. of log documents) carry their credentials there
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	query := u.Query()
+	for k := range query {
+		query[k] = []string{redacted}
+	}
+	redactedURL := *u
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.String()
+}
+
+func redactHeaders(headers http.Header) string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		value := strings.Join(headers[k], ",")
+		if redactedHeaders[strings.ToLower(k)] {
+			value = redacted
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", k, value))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// redactBody masks secret fields of JSON bodies, non JSON bodies are never logged
+// since they may carry raw secrets (e.g. a newly created token)
+func redactBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+	if _, ok := parsed.(string); ok {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+	out, err := json.Marshal(redactValue(parsed))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+	if len(out) > maxLoggedBody {
+		return string(out[:maxLoggedBody]) + truncatedSuffix
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range value {
+			if isSecretField(k) {
+				if inner != nil && inner != "" {
+					value[k] = redacted
+				}
+				continue
+			}
+			value[k] = redactValue(inner)
+		}
+		return value
+	case []interface{}:
+		for i := range value {
+			value[i] = redactValue(value[i])
+		}
+		return value
+	}
+	return v
+}
+
+func isSecretField(key string) bool {
+	key = strings.ToLower(key)
+	for _, f := range redactedFields {
+		if strings.Contains(key, f) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package codefresh
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(msg, keysAndValues))
+}
+
+func TestDebugLoggingRedactsSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"metadata":{"name":"github"},"spec":{"type":"git.github","data":{"auth":{"type":"basic","password":"hunter2"}}}}`))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	cf := New(&ClientOptions{
+		Host:   server.URL,
+		Debug:  true,
+		Logger: logger,
+		Auth:   AuthOptions{Token: "secret-token"},
+	})
+	err, ctx := cf.Contexts().GetGitContextByName("github")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", ctx.Spec.Data.Auth.Password)
+	assert.Len(t, logger.lines, 2)
+	all := strings.Join(logger.lines, "\n")
+	assert.NotContains(t, all, "secret-token")
+	assert.NotContains(t, all, "hunter2")
+	assert.Contains(t, all, "Authorization: REDACTED")
+	assert.Contains(t, all, "status 200")
+	assert.Contains(t, all, `"password":"REDACTED"`)
+}
+
+func TestDebugLoggingSkipsNonJSONBodies(t *testing.T) {
+	logs := strings.Repeat("step output\n", 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(logs))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	cf := New(&ClientOptions{Host: "https://g.codefresh.io", Debug: true, Logger: logger}).(*codefresh)
+	resp, err := cf.requestURL(context.Background(), server.URL+"/logs/build.txt?X-Amz-Signature=abc123&X-Amz-Expires=60")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, logs, string(body))
+
+	all := strings.Join(logger.lines, "\n")
+	assert.NotContains(t, all, "abc123")
+	assert.NotContains(t, all, "step output")
+	assert.Contains(t, all, "X-Amz-Signature=REDACTED")
+}
+
+func TestDebugLoggingKeepsLargeJSONBodies(t *testing.T) {
+	payload := `{"data":"` + strings.Repeat("x", maxPeekedBody) + `"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	cf := New(&ClientOptions{Host: server.URL, Debug: true, Logger: logger}).(*codefresh)
+	resp, err := cf.requestAPIWithContext(context.Background(), &requestOptions{path: "/api/large", method: "GET"})
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, string(body))
+	assert.Contains(t, strings.Join(logger.lines, "\n"), fmt.Sprintf("<more than %d bytes>", maxPeekedBody))
+}
@@ -0,0 +1,343 @@
+package codefresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultLogInterval = 2 * time.Second
+
+// firebase push ids start with 8 characters encoding the creation time in milliseconds
+const pushIDChars = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+
+type (
+	// ILogsAPI reads build logs from the log document referenced by the build progress,
+	// a context-only API like ITriggerAPI, hence no WithContext suffix
+	ILogsAPI interface {
+		Get(ctx context.Context, buildID string) (*BuildLog, error)
+		Follow(ctx context.Context, buildID string, opt *LogOptions, fn func(LogLine) error) error
+		Stream(ctx context.Context, buildID string, opt *LogOptions) (<-chan LogLine, <-chan error)
+		Reader(ctx context.Context, buildID string, opt *LogOptions) io.ReadCloser
+	}
+
+	logs struct {
+		codefresh *codefresh
+	}
+
+	// LogOptions - what Follow, Stream and Reader deliver
+	LogOptions struct {
+		// Follow - keep delivering new output until the build finishes, otherwise stop after the current output
+		Follow bool
+		// Interval - how often the log document is fetched while following (default 2s)
+		Interval time.Duration
+		// Steps - only deliver the output of these steps
+		Steps []string
+	}
+
+	// BuildLog is the log document of a build
+	BuildLog struct {
+		Steps []*StepLog
+	}
+
+	// StepLog is the output of a single step
+	StepLog struct {
+		Name     string
+		Title    string
+		Status   string
+		Started  time.Time
+		Finished time.Time
+		// ExitCode - nil while the step runs or when it did not run a container
+		ExitCode *int
+		Lines    []LogLine
+
+		// partial - the last line has not received its newline yet
+		partial bool
+	}
+
+	// LogLine is a line of output, Timestamp is zero when the log document does not carry it
+	LogLine struct {
+		Step      string
+		Timestamp time.Time
+		Text      string
+	}
+
+	logDocument struct {
+		Steps []*logDocumentStep `json:"steps"`
+	}
+
+	logDocumentStep struct {
+		Name              string            `json:"name"`
+		Title             string            `json:"title"`
+		Status            string            `json:"status"`
+		CreationTimeStamp float64           `json:"creationTimeStamp"`
+		FinishTimeStamp   float64           `json:"finishTimeStamp"`
+		ExitCode          *int              `json:"exitCode"`
+		Logs              map[string]string `json:"logs"`
+	}
+)
+
+func newLogsAPI(codefresh *codefresh) ILogsAPI {
+	return &logs{codefresh}
+}
+
+// Get - downloads the current log document of the build, complete once the build finished
+func (l *logs) Get(ctx context.Context, buildID string) (*BuildLog, error) {
+	wf, err := l.codefresh.Workflows().GetWithContext(ctx, buildID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := l.codefresh.getLogDocument(ctx, wf)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("failed to get logs of build %s: log document is not available yet", buildID)
+	}
+	return doc.buildLog(), nil
+}
+
+// Follow - calls fn for every line of output in order, with Follow set new lines are delivered
+// as they arrive until the build finishes, return ErrStopPagination from fn to stop early.
+// The output is read from the log document, which is available once the build progress reports
+// its location, until then nothing is delivered. Every poll downloads the whole document,
+// so Interval trades the delay of new lines for traffic on long builds. A line is delivered
+// once its newline arrives, or when the build finishes without one
+func (l *logs) Follow(ctx context.Context, buildID string, opt *LogOptions, fn func(LogLine) error) error {
+	if opt == nil {
+		opt = &LogOptions{}
+	}
+	interval := opt.Interval
+	if interval <= 0 {
+		interval = defaultLogInterval
+	}
+	// lines already delivered per entry of the log document, a retried step is a second entry with the same name
+	delivered := map[int]int{}
+	for {
+		wf, err := l.codefresh.Workflows().GetWithContext(ctx, buildID)
+		if err != nil {
+			return err
+		}
+		finished := IsTerminalStatus(wf.Status)
+		doc, err := l.codefresh.getLogDocument(ctx, wf)
+		if err != nil {
+			return err
+		}
+		if doc == nil && (finished || !opt.Follow) {
+			return fmt.Errorf("failed to get logs of build %s: log document is not available", buildID)
+		}
+		if doc != nil {
+			for i, step := range doc.buildLog().Steps {
+				if len(opt.Steps) > 0 && !containsString(opt.Steps, step.Name) {
+					continue
+				}
+				lines := step.Lines
+				if step.partial && opt.Follow && !finished {
+					// the rest of the line is still being written
+					lines = lines[:len(lines)-1]
+				}
+				from := delivered[i]
+				if from > len(lines) {
+					from = len(lines)
+				}
+				for _, line := range lines[from:] {
+					delivered[i]++
+					if err := fn(line); err != nil {
+						if errors.Is(err, ErrStopPagination) {
+							return nil
+						}
+						return err
+					}
+				}
+			}
+		}
+		if finished || !opt.Follow {
+			return nil
+		}
+		if err := sleepWithContext(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Stream - Follow delivered over a channel, both channels are closed when the output ends
+func (l *logs) Stream(ctx context.Context, buildID string, opt *LogOptions) (<-chan LogLine, <-chan error) {
+	lines := make(chan LogLine)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		defer close(errs)
+		err := l.Follow(ctx, buildID, opt, func(line LogLine) error {
+			select {
+			case lines <- line:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return lines, errs
+}
+
+// Reader - the text of the output, one line per LogLine, closing the reader stops following
+func (l *logs) Reader(ctx context.Context, buildID string, opt *LogOptions) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		err := l.Follow(ctx, buildID, opt, func(line LogLine) error {
+			_, err := io.WriteString(pw, line.Text+"\n")
+			return err
+		})
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// getLogDocument downloads the log document of the build, nil when its location is not known yet
+func (c *codefresh) getLogDocument(ctx context.Context, wf *Workflow) (*logDocument, error) {
+	if wf.Progress == "" {
+		return nil, nil
+	}
+	p, err := c.Progresses().GetWithContext(ctx, wf.Progress)
+	if err != nil {
+		return nil, err
+	}
+	if p.Location.URL == "" {
+		return nil, nil
+	}
+	resp, err := c.requestURL(ctx, p.Location.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	doc := &logDocument{}
+	if err := c.decodeResponseInto(resp, doc); err != nil {
+		return nil, fmt.Errorf("failed to decode log document of build %s: %w", wf.ID, err)
+	}
+	return doc, nil
+}
+
+func (d *logDocument) buildLog() *BuildLog {
+	log := &BuildLog{}
+	for _, s := range d.Steps {
+		step := &StepLog{
+			Name:     s.Name,
+			Title:    s.Title,
+			Status:   s.Status,
+			Started:  unixSeconds(s.CreationTimeStamp),
+			Finished: unixSeconds(s.FinishTimeStamp),
+			ExitCode: s.ExitCode,
+		}
+		// chunks are written as the output arrives and may end in the middle of a line,
+		// a line carries the timestamp of the chunk it starts in
+		var line strings.Builder
+		var lineTS time.Time
+		for _, key := range sortedLogKeys(s.Logs) {
+			ts := pushIDTime(key)
+			chunk := s.Logs[key]
+			for chunk != "" {
+				if !step.partial {
+					lineTS, step.partial = ts, true
+				}
+				i := strings.IndexByte(chunk, '\n')
+				if i < 0 {
+					line.WriteString(chunk)
+					break
+				}
+				line.WriteString(chunk[:i])
+				step.Lines = append(step.Lines, LogLine{Step: s.Name, Timestamp: lineTS, Text: strings.TrimSuffix(line.String(), "\r")})
+				line.Reset()
+				step.partial = false
+				chunk = chunk[i+1:]
+			}
+		}
+		if step.partial {
+			step.Lines = append(step.Lines, LogLine{Step: s.Name, Timestamp: lineTS, Text: strings.TrimSuffix(line.String(), "\r")})
+		}
+		log.Steps = append(log.Steps, step)
+	}
+	return log
+}
+
+// Step returns the log of the named step, or nil
+func (b *BuildLog) Step(name string) *StepLog {
+	for _, step := range b.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+	return nil
+}
+
+// sortedLogKeys orders the chunks of a step, keys are either sequence numbers or firebase push ids
+func sortedLogKeys(chunks map[string]string) []string {
+	keys := make([]string, 0, len(chunks))
+	numeric := true
+	for k := range chunks {
+		keys = append(keys, k)
+		if _, err := strconv.Atoi(k); err != nil {
+			numeric = false
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if numeric {
+			a, _ := strconv.Atoi(keys[i])
+			b, _ := strconv.Atoi(keys[j])
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func pushIDTime(key string) time.Time {
+	if len(key) != 20 {
+		return time.Time{}
+	}
+	var ms int64
+	for _, c := range key[:8] {
+		i := strings.IndexRune(pushIDChars, c)
+		if i < 0 {
+			return time.Time{}
+		}
+		ms = ms*64 + int64(i)
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+func unixSeconds(ts float64) time.Time {
+	if ts <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(ts*float64(time.Second))).UTC()
+}
+
+// requestURL fetches an absolute URL, the credentials are only sent to the Codefresh host
+func (c *codefresh) requestURL(ctx context.Context, rawURL string) (*http.Response, error) {
+	if strings.HasPrefix(rawURL, c.host+"/") {
+		return c.requestAPIWithContext(ctx, &requestOptions{
+			path:   strings.TrimPrefix(rawURL, c.host),
+			method: "GET",
+		})
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
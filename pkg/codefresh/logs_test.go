@@ -0,0 +1,167 @@
+package codefresh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsFollow(t *testing.T) {
+	polls := 0
+	documents := []string{
+		`{"steps":[{"name":"clone","status":"running","creationTimeStamp":1590000000,"logs":{"-M7bq000000000000000":"cloning\n"}}]}`,
+		`{"steps":[
+			{"name":"clone","status":"success","creationTimeStamp":1590000000,"finishTimeStamp":1590000010,"exitCode":0,
+			 "logs":{"-M7bq000000000000000":"cloning\n","-M7bq001000000000000":"done\nok\n"}},
+			{"name":"test","status":"error","exitCode":2,"logs":{"0":"FAIL\r\n"}}]}`,
+	}
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "", r.Header.Get("Authorization"))
+		w.Write([]byte(documents[polls]))
+	}))
+	defer storage.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/builds/b1":
+			status := "running"
+			if polls > 0 {
+				status = "error"
+			}
+			w.Write([]byte(`{"id":"b1","progress":"p1","status":"` + status + `"}`))
+		case "/api/progress/p1":
+			fmt.Fprintf(w, `{"id":"p1","location":{"type":"s3","url":"%s/logs/p1.json"}}`, storage.URL)
+		}
+	}))
+	defer api.Close()
+
+	cf := New(&ClientOptions{Host: api.URL, Auth: AuthOptions{Token: "secret"}})
+	lines := []string{}
+	err := cf.Logs().Follow(context.Background(), "b1", &LogOptions{Follow: true, Interval: time.Millisecond}, func(line LogLine) error {
+		lines = append(lines, line.Step+": "+line.Text)
+		polls = 1
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"clone: cloning", "clone: done", "clone: ok", "test: FAIL"}, lines)
+
+	log, err := cf.Logs().Get(context.Background(), "b1")
+	assert.NoError(t, err)
+	clone := log.Step("clone")
+	assert.Equal(t, 10*time.Second, clone.Finished.Sub(clone.Started))
+	assert.Equal(t, 0, *clone.ExitCode)
+	assert.Equal(t, 2, *log.Step("test").ExitCode)
+	assert.False(t, clone.Lines[0].Timestamp.IsZero())
+	assert.True(t, clone.Lines[0].Timestamp.Before(clone.Lines[1].Timestamp))
+
+	r := cf.Logs().Reader(context.Background(), "b1", &LogOptions{Steps: []string{"test"}})
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "FAIL\n", string(data))
+}
+
+type closeCounter struct {
+	io.ReadCloser
+	open   *int
+	closed bool
+}
+
+func (c *closeCounter) Close() error {
+	if !c.closed {
+		c.closed = true
+		*c.open--
+	}
+	return c.ReadCloser.Close()
+}
+
+func TestLogsFollowRetriedStep(t *testing.T) {
+	polls := 0
+	documents := []string{
+		`{"steps":[{"name":"unit","status":"error","logs":{"0":"a\nb\nc\n"}}]}`,
+		`{"steps":[{"name":"unit","status":"error","logs":{"0":"a\nb\nc\n"}},{"name":"unit","status":"running","logs":{"0":"retry\n"}}]}`,
+		`{"steps":[{"name":"unit","status":"error","logs":{"0":"a\nb\nc\n"}},{"name":"unit","status":"success","logs":{"0":"retry\n","1":"passed\n"}}]}`,
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/builds/b1":
+			status := "running"
+			if polls == len(documents)-1 {
+				status = "success"
+			}
+			w.Write([]byte(`{"id":"b1","progress":"p1","status":"` + status + `"}`))
+		case "/api/progress/p1":
+			fmt.Fprintf(w, `{"id":"p1","location":{"type":"db","url":"%s/api/progress/download/p1"}}`, server.URL)
+		case "/api/progress/download/p1":
+			w.Write([]byte(documents[polls]))
+			if polls < len(documents)-1 {
+				polls++
+			}
+		}
+	}))
+	defer server.Close()
+
+	open := 0
+	countBodies := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err == nil {
+				open++
+				resp.Body = &closeCounter{ReadCloser: resp.Body, open: &open}
+			}
+			return resp, err
+		})
+	}
+	cf := New(&ClientOptions{Host: server.URL, Middlewares: []Middleware{countBodies}})
+	lines := []string{}
+	err := cf.Logs().Follow(context.Background(), "b1", &LogOptions{Follow: true, Interval: time.Millisecond}, func(line LogLine) error {
+		lines = append(lines, line.Text)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "retry", "passed"}, lines)
+	assert.Equal(t, 0, open, "response bodies left open")
+}
+
+func TestLogsFollowLineSplitAcrossChunks(t *testing.T) {
+	polls := 0
+	documents := []string{
+		`{"steps":[{"name":"build","status":"running","logs":{"0":"step 1\nprogr"}}]}`,
+		`{"steps":[{"name":"build","status":"running","logs":{"0":"step 1\nprogr","1":"ess 50%"}}]}`,
+		`{"steps":[{"name":"build","status":"running","logs":{"0":"step 1\nprogr","1":"ess 50%","2":"\nstep 2\nlast"}}]}`,
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/builds/b1":
+			status := "running"
+			if polls == len(documents)-1 {
+				status = "success"
+			}
+			w.Write([]byte(`{"id":"b1","progress":"p1","status":"` + status + `"}`))
+		case "/api/progress/p1":
+			fmt.Fprintf(w, `{"id":"p1","location":{"type":"db","url":"%s/api/progress/download/p1"}}`, server.URL)
+		case "/api/progress/download/p1":
+			w.Write([]byte(documents[polls]))
+			if polls < len(documents)-1 {
+				polls++
+			}
+		}
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	lines := []string{}
+	err := cf.Logs().Follow(context.Background(), "b1", &LogOptions{Follow: true, Interval: time.Millisecond}, func(line LogLine) error {
+		lines = append(lines, line.Text)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"step 1", "progress 50%", "step 2", "last"}, lines)
+}
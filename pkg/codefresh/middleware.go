@@ -0,0 +1,44 @@
+package codefresh
+
+import "net/http"
+
+type (
+	// Middleware wraps the next http.RoundTripper in the chain, it may mutate the request,
+	// observe the response or short-circuit the call by not invoking next at all
+	Middleware func(next http.RoundTripper) http.RoundTripper
+
+	// RoundTripperFunc adapts a plain function to http.RoundTripper
+	RoundTripperFunc func(*http.Request) (*http.Response, error)
+)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithHeader returns a middleware that sets a static header on every request
+func WithHeader(key, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set(key, value)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// withMiddlewares returns a shallow copy of client whose transport runs through the middlewares,
+// the first middleware is the outermost one
+func withMiddlewares(client *http.Client, middlewares []Middleware) *http.Client {
+	if len(middlewares) == 0 {
+		return client
+	}
+	var transport http.RoundTripper = http.DefaultTransport
+	if client.Transport != nil {
+		transport = client.Transport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+	wrapped := *client
+	wrapped.Transport = transport
+	return &wrapped
+}
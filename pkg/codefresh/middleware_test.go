@@ -0,0 +1,55 @@
+package codefresh
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Tenant") + "," + r.Header.Get("X-Order")))
+	}))
+	defer server.Close()
+
+	order := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Add("X-Order", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	cf := New(&ClientOptions{
+		Host:        server.URL,
+		Middlewares: []Middleware{WithHeader("X-Tenant", "acme"), order("first"), order("second")},
+	})
+	res, err := cf.Pipelines().Run("pipeline", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "acme,first", res)
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	cf := New(&ClientOptions{
+		Host: "http://unreachable.invalid",
+		Middlewares: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(`{"id":"1","status":"success"}`)),
+					Header:     http.Header{},
+					Request:    req,
+				}, nil
+			})
+		}},
+	})
+	wf, err := cf.Workflows().Get("1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "success", wf.Status)
+}
@@ -76,6 +76,22 @@ func (_m *Codefresh) Gitops() codefresh.GitopsAPI {
 	return r0
 }
 
+// Logs provides a mock function with given fields:
+func (_m *Codefresh) Logs() codefresh.ILogsAPI {
+	ret := _m.Called()
+
+	var r0 codefresh.ILogsAPI
+	if rf, ok := ret.Get(0).(func() codefresh.ILogsAPI); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(codefresh.ILogsAPI)
+		}
+	}
+
+	return r0
+}
+
 // Pipelines provides a mock function with given fields:
 func (_m *Codefresh) Pipelines() codefresh.IPipelineAPI {
 	ret := _m.Called()
@@ -108,6 +124,36 @@ func (_m *Codefresh) Progresses() codefresh.IProgressAPI {
 	return r0
 }
 
+// Projects provides a mock function with given fields:
+func (_m *Codefresh) Projects() codefresh.IProjectAPI {
+	ret := _m.Called()
+
+	var r0 codefresh.IProjectAPI
+	if rf, ok := ret.Get(0).(func() codefresh.IProjectAPI); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(codefresh.IProjectAPI)
+		}
+	}
+
+	return r0
+}
+
+// RateLimiterStats provides a mock function with given fields:
+func (_m *Codefresh) RateLimiterStats() codefresh.RateLimiterStats {
+	ret := _m.Called()
+
+	var r0 codefresh.RateLimiterStats
+	if rf, ok := ret.Get(0).(func() codefresh.RateLimiterStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(codefresh.RateLimiterStats)
+	}
+
+	return r0
+}
+
 // RuntimeEnvironments provides a mock function with given fields:
 func (_m *Codefresh) RuntimeEnvironments() codefresh.IRuntimeEnvironmentAPI {
 	ret := _m.Called()
@@ -140,6 +186,22 @@ func (_m *Codefresh) Tokens() codefresh.ITokenAPI {
 	return r0
 }
 
+// Triggers provides a mock function with given fields:
+func (_m *Codefresh) Triggers() codefresh.ITriggerAPI {
+	ret := _m.Called()
+
+	var r0 codefresh.ITriggerAPI
+	if rf, ok := ret.Get(0).(func() codefresh.ITriggerAPI); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(codefresh.ITriggerAPI)
+		}
+	}
+
+	return r0
+}
+
 // Users provides a mock function with given fields:
 func (_m *Codefresh) Users() codefresh.UsersAPI {
 	ret := _m.Called()
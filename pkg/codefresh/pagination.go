@@ -0,0 +1,129 @@
+package codefresh
+
+import (
+	"context"
+	"errors"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+)
+
+const defaultPageSize = 50
+
+// ErrStopPagination can be returned from a ForEach callback to stop walking pages without failing
+var ErrStopPagination = errors.New("stop pagination")
+
+type (
+	// PaginationOptions
+	PaginationOptions struct {
+
+		// PageSize - number of items requested per page (default 50)
+		PageSize int
+
+		// Limit - maximum number of items to return, 0 returns everything
+		Limit int
+	}
+
+	// cursorPage is a single page of a GraphQL connection, adapted from model.PageInfo
+	cursorPage struct {
+		count     int
+		endCursor *string
+		hasNext   bool
+		// item hands the i-th node of the page to the caller
+		item func(i int) error
+	}
+
+	pageFetcher func(ctx context.Context, args model.SlicePaginationArgs) (*cursorPage, error)
+)
+
+func newCursorPage(count int, info *model.PageInfo, item func(i int) error) *cursorPage {
+	p := &cursorPage{count: count, item: item}
+	if info != nil {
+		p.endCursor = info.EndCursor
+		p.hasNext = info.HasNextPage
+	}
+	return p
+}
+
+// paginate keeps fetching pages until the connection is exhausted, the limit is reached or the callback stops it
+func paginate(ctx context.Context, opt *PaginationOptions, fetch pageFetcher) error {
+	if opt == nil {
+		opt = &PaginationOptions{}
+	}
+	size := opt.PageSize
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	seen := 0
+	var after *string
+	for {
+		first := size
+		if opt.Limit > 0 && opt.Limit-seen < first {
+			first = opt.Limit - seen
+		}
+		page, err := fetch(ctx, model.SlicePaginationArgs{First: &first, After: after})
+		if err != nil {
+			return err
+		}
+		for i := 0; i < page.count; i++ {
+			if opt.Limit > 0 && seen >= opt.Limit {
+				return nil
+			}
+			if err := page.item(i); err != nil {
+				if errors.Is(err, ErrStopPagination) {
+					return nil
+				}
+				return err
+			}
+			seen++
+		}
+		if !page.hasNext || page.endCursor == nil || page.count == 0 || (opt.Limit > 0 && seen >= opt.Limit) {
+			return nil
+		}
+		after = page.endCursor
+	}
+}
+
+type (
+	// offsetPage is a single page of a REST list endpoint
+	offsetPage struct {
+		count int
+		// total - number of items on the server, 0 when the endpoint does not report it
+		total int
+		item  func(i int) error
+	}
+
+	offsetFetcher func(ctx context.Context, offset int, limit int) (*offsetPage, error)
+)
+
+// paginateOffset walks a limit/offset REST endpoint starting at offset until the reported total is reached,
+// or until a short page is returned by endpoints that do not report it
+func paginateOffset(ctx context.Context, offset int, pageSize int, fetch offsetFetcher) error {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	for {
+		page, err := fetch(ctx, offset, pageSize)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < page.count; i++ {
+			if err := page.item(i); err != nil {
+				if errors.Is(err, ErrStopPagination) {
+					return nil
+				}
+				return err
+			}
+		}
+		offset += page.count
+		// the server may cap the page size below the requested one, a short page only ends the walk without a total
+		if page.total > 0 {
+			if offset >= page.total || page.count == 0 {
+				return nil
+			}
+			continue
+		}
+		if page.count < pageSize {
+			return nil
+		}
+	}
+}
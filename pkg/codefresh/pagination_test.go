@@ -0,0 +1,162 @@
+package codefresh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// componentsServer serves total components in pages of the requested size
+func componentsServer(t *testing.T, total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Variables struct {
+				Pagination struct {
+					First int     `json:"first"`
+					After *string `json:"after"`
+				} `json:"pagination"`
+			} `json:"variables"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		start := 0
+		if req.Variables.Pagination.After != nil {
+			fmt.Sscanf(*req.Variables.Pagination.After, "%d", &start)
+		}
+		end := start + req.Variables.Pagination.First
+		if end > total {
+			end = total
+		}
+		edges := []map[string]interface{}{}
+		for i := start; i < end; i++ {
+			edges = append(edges, map[string]interface{}{
+				"node": map[string]interface{}{"metadata": map[string]interface{}{"name": fmt.Sprintf("c%d", i)}},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"components": map[string]interface{}{
+					"edges": edges,
+					"pageInfo": map[string]interface{}{
+						"endCursor":   fmt.Sprintf("%d", end),
+						"hasNextPage": end < total,
+					},
+				},
+			},
+		})
+	}))
+}
+
+func TestPaginationWalksAllPages(t *testing.T) {
+	server := componentsServer(t, 120)
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	components, err := cf.V2().Component().List(context.Background(), "runtime")
+
+	assert.NoError(t, err)
+	assert.Len(t, components, 120)
+	assert.Equal(t, "c119", components[119].Metadata.Name)
+}
+
+func TestPaginationLimitAndStop(t *testing.T) {
+	server := componentsServer(t, 120)
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	count := 0
+	err := cf.V2().Component().ForEach(context.Background(), "runtime", &PaginationOptions{PageSize: 7, Limit: 30}, func(c *model.Component) error {
+		count++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 30, count)
+
+	count = 0
+	err = cf.V2().Component().ForEach(context.Background(), "runtime", &PaginationOptions{PageSize: 7}, func(c *model.Component) error {
+		count++
+		if count == 10 {
+			return ErrStopPagination
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, count)
+}
+
+func TestOffsetPaginationPipelines(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		var offset, limit int
+		fmt.Sscanf(r.URL.Query().Get("offset"), "%d", &offset)
+		fmt.Sscanf(r.URL.Query().Get("limit"), "%d", &limit)
+		docs := []map[string]interface{}{}
+		for i := offset; i < offset+limit && i < 25; i++ {
+			docs = append(docs, map[string]interface{}{"metadata": map[string]interface{}{"name": fmt.Sprintf("p%d", i)}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"docs": docs, "count": 25})
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	pipelines, err := cf.Pipelines().ListAllWithContext(context.Background(), &PipelineListOptions{Limit: 10, ProjectID: "proj"})
+
+	assert.NoError(t, err)
+	assert.Len(t, pipelines, 25)
+	assert.Equal(t, "p24", pipelines[24].Metadata.Name)
+	assert.Equal(t, []string{
+		"limit=10&projectId=proj",
+		"limit=10&offset=10&projectId=proj",
+		"limit=10&offset=20&projectId=proj",
+	}, queries)
+}
+
+func TestOffsetPaginationCappedPageSize(t *testing.T) {
+	// the server returns at most 4 items whatever the requested limit, the total tells there are more
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var offset int
+		fmt.Sscanf(r.URL.Query().Get("offset"), "%d", &offset)
+		docs := []map[string]interface{}{}
+		for i := offset; i < offset+4 && i < 10; i++ {
+			docs = append(docs, map[string]interface{}{"metadata": map[string]interface{}{"name": fmt.Sprintf("p%d", i)}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"docs": docs, "count": 10})
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	pipelines, err := cf.Pipelines().ListAllWithContext(context.Background(), &PipelineListOptions{Limit: 50})
+
+	assert.NoError(t, err)
+	assert.Len(t, pipelines, 10)
+	assert.Equal(t, "p9", pipelines[9].Metadata.Name)
+}
+
+func TestOffsetPaginationIgnoredOffset(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/api/runtime-environments", r.URL.Path)
+		page := []map[string]interface{}{}
+		for i := 0; i < 5; i++ {
+			page = append(page, map[string]interface{}{"metadata": map[string]string{"name": fmt.Sprintf("re-%d", i)}})
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	res, err := cf.RuntimeEnvironments().ListAllWithContext(context.Background(), &RuntimeEnvironmentListOptions{Limit: 5})
+	assert.NoError(t, err)
+	assert.Len(t, res, 5)
+	assert.Equal(t, 2, requests)
+}
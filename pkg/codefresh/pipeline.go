@@ -1,8 +1,11 @@
 package codefresh
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 )
@@ -11,7 +14,21 @@ type (
 	// IPipelineAPI declers Codefresh pipeline API
 	IPipelineAPI interface {
 		List(qs map[string]string) ([]*Pipeline, error)
+		ListWithContext(ctx context.Context, qs map[string]string) ([]*Pipeline, error)
+		ListPageWithContext(ctx context.Context, opt *PipelineListOptions) (*PipelineList, error)
+		ListAllWithContext(ctx context.Context, opt *PipelineListOptions) ([]*Pipeline, error)
+		ForEachWithContext(ctx context.Context, opt *PipelineListOptions, fn func(*Pipeline) error) error
 		Run(string, *RunOptions) (string, error)
+		RunWithContext(ctx context.Context, name string, options *RunOptions) (*RunResult, error)
+		GetWithContext(ctx context.Context, nameOrID string) (*Pipeline, error)
+		CreateWithContext(ctx context.Context, pipeline *Pipeline) (*Pipeline, error)
+		UpdateWithContext(ctx context.Context, pipeline *Pipeline) (*Pipeline, error)
+		PatchWithContext(ctx context.Context, nameOrID string, mutate func(*Pipeline) error) (*Pipeline, error)
+		DeleteWithContext(ctx context.Context, nameOrID string) error
+		ExportWithContext(ctx context.Context, opt *ExportOptions) (*Bundle, error)
+		ImportWithContext(ctx context.Context, bundle *Bundle, opt *ImportOptions) (*ImportResult, error)
+		DiffWithContext(ctx context.Context, desired *Pipeline) (PipelineDiff, error)
+		ApplyWithContext(ctx context.Context, desired *Pipeline) (*ApplyResult, error)
 	}
 
 	PipelineMetadata struct {
@@ -30,24 +47,10 @@ type (
 		UpdatedAt          time.Time `json:"updated_at"`
 		Project            string    `json:"project"`
 		ID                 string    `json:"id"`
-	}
+		// Revision - bumped by the server on every change, an UpdateWithContext carrying a stale revision fails with a conflict
+		Revision int `json:"revision,omitempty"`
 
-	PipelineSpec struct {
-		Triggers []struct {
-			Type     string   `json:"type"`
-			Repo     string   `json:"repo"`
-			Events   []string `json:"events"`
-			Provider string   `json:"provider"`
-			Context  string   `json:"context"`
-		} `json:"triggers"`
-		Contexts  []interface{} `json:"contexts"`
-		Variables []struct {
-			Key   string `json:"key"`
-			Value string `json:"value"`
-		} `json:"variables"`
-		Steps  map[string]interface{} `json:"steps"`
-		Stages []interface{}          `json:"stages"`
-		Mode   string                 `json:"mode"`
+		Extra map[string]json.RawMessage `json:"-"`
 	}
 
 	Pipeline struct {
@@ -55,6 +58,26 @@ type (
 		Spec     PipelineSpec     `json:"spec"`
 	}
 
+	// PipelineListOptions - filters for listing pipelines, Limit is used as the page size when iterating
+	PipelineListOptions struct {
+		Limit  int `url:"limit,omitempty"`
+		Offset int `url:"offset,omitempty"`
+		// Name - regular expression matched against the pipeline name
+		Name string `url:"name,omitempty"`
+		// Labels - e.g. "tag:production"
+		Labels    []string `url:"labels,omitempty"`
+		ProjectID string   `url:"projectId,omitempty"`
+		// Sort - field to sort by, prefixed with "-" for descending order
+		Sort string `url:"sort,omitempty"`
+	}
+
+	// PipelineList - a single page of pipelines
+	PipelineList struct {
+		Pipelines []*Pipeline
+		// Total - number of pipelines matching the filters
+		Total int
+	}
+
 	getPipelineResponse struct {
 		Docs  []*Pipeline `json:"docs"`
 		Count int         `json:"count"`
@@ -67,40 +90,330 @@ type (
 	RunOptions struct {
 		Branch    string
 		Variables map[string]string
+		// SHA - run on a specific commit instead of the branch head
+		SHA string
+		// TriggerID - the git trigger whose settings are used for the build
+		TriggerID string
+		// Contexts - shared configuration contexts injected into the build
+		Contexts []string
+		// NoCache - ignore the docker layer cache
+		NoCache bool
+		// ResetVolume - start from an empty shared volume
+		ResetVolume bool
+		// NoCfCache - ignore the Codefresh distributed cache
+		NoCfCache bool
+		// Skip - steps to skip, mutually exclusive with Only
+		Skip []string
+		// Only - the only steps to run
+		Only []string
+		// Yaml - codefresh.yml content that overrides the pipeline definition for this build
+		Yaml string
+		// RuntimeEnvironment - runtime environment name to run the build on
+		RuntimeEnvironment string
+		// Annotations - key/value pairs attached to the build
+		Annotations map[string]string
+		// Debug - run the build in debug mode
+		Debug bool
+		// Priority - build priority, higher values are scheduled first
+		Priority int
+	}
+
+	// RunResult is returned when a build is started
+	RunResult struct {
+		// ID - the build (workflow) ID, can be passed to Workflows()
+		ID string
+		// URL - link to the build in the Codefresh UI
+		URL string
+	}
+
+	runOptionsFlags struct {
+		NoCache     bool `json:"noCache,omitempty"`
+		ResetVolume bool `json:"resetVolume,omitempty"`
+		NoCfCache   bool `json:"noCfCache,omitempty"`
+	}
+
+	runRequest struct {
+		Branch             string            `json:"branch"`
+		Variables          map[string]string `json:"variables"`
+		SHA                string            `json:"sha,omitempty"`
+		Trigger            string            `json:"trigger,omitempty"`
+		Contexts           []string          `json:"contexts,omitempty"`
+		Options            runOptionsFlags   `json:"options"`
+		Skip               []string          `json:"skip,omitempty"`
+		Only               []string          `json:"only,omitempty"`
+		UserYamlDescriptor string            `json:"userYamlDescriptor,omitempty"`
+		RuntimeEnvironment string            `json:"runtimeEnvironment,omitempty"`
+		Annotations        []Annotation      `json:"annotations,omitempty"`
+		IsDebug            bool              `json:"isDebug,omitempty"`
+		Priority           int               `json:"priority,omitempty"`
 	}
 )
 
+// maxPatchAttempts - how many times PatchWithContext re-reads the pipeline after losing an update race
+const maxPatchAttempts = 5
+
 func newPipelineAPI(codefresh *codefresh) IPipelineAPI {
 	return &pipeline{codefresh}
 }
 
-// Get - returns pipelines from API
+func (m PipelineMetadata) MarshalJSON() ([]byte, error) {
+	type alias PipelineMetadata
+	return marshalWithExtra(alias(m), m.Extra)
+}
+
+func (m *PipelineMetadata) UnmarshalJSON(data []byte) (err error) {
+	type alias PipelineMetadata
+	m.Extra, err = unmarshalWithExtra(data, (*alias)(m))
+	return err
+}
+
+// List - returns pipelines from API
+//
+// Deprecated: use ListWithContext
 func (p *pipeline) List(qs map[string]string) ([]*Pipeline, error) {
+	return p.ListWithContext(context.Background(), qs)
+}
+
+// ListWithContext - returns pipelines from API
+func (p *pipeline) ListWithContext(ctx context.Context, qs map[string]string) ([]*Pipeline, error) {
 	r := &getPipelineResponse{}
-	resp, err := p.codefresh.requestAPI(&requestOptions{
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/pipelines",
 		method: "GET",
 		qs:     qs,
 	})
+	if err != nil {
+		return nil, err
+	}
 	err = p.codefresh.decodeResponseInto(resp, r)
 	return r.Docs, err
 }
 
+// ListPageWithContext - returns a single page of pipelines and the total amount matching the filters
+func (p *pipeline) ListPageWithContext(ctx context.Context, opt *PipelineListOptions) (*PipelineList, error) {
+	if opt == nil {
+		opt = &PipelineListOptions{}
+	}
+	r := &getPipelineResponse{}
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   "/api/pipelines",
+		method: "GET",
+		qs:     opt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = p.codefresh.decodeResponseInto(resp, r)
+	if err != nil {
+		return nil, err
+	}
+	return &PipelineList{Pipelines: r.Docs, Total: r.Count}, nil
+}
+
+// ListAllWithContext - returns every pipeline matching the filters, walking all pages
+func (p *pipeline) ListAllWithContext(ctx context.Context, opt *PipelineListOptions) ([]*Pipeline, error) {
+	pipelines := []*Pipeline{}
+	err := p.ForEachWithContext(ctx, opt, func(pl *Pipeline) error {
+		pipelines = append(pipelines, pl)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pipelines, nil
+}
+
+// ForEachWithContext - calls fn for every pipeline matching the filters, fetching pages lazily
+func (p *pipeline) ForEachWithContext(ctx context.Context, opt *PipelineListOptions, fn func(*Pipeline) error) error {
+	filters := PipelineListOptions{}
+	if opt != nil {
+		filters = *opt
+	}
+	return paginateOffset(ctx, filters.Offset, filters.Limit, func(ctx context.Context, offset int, limit int) (*offsetPage, error) {
+		filters.Offset = offset
+		filters.Limit = limit
+		list, err := p.ListPageWithContext(ctx, &filters)
+		if err != nil {
+			return nil, err
+		}
+		return &offsetPage{count: len(list.Pipelines), total: list.Total, item: func(i int) error {
+			return fn(list.Pipelines[i])
+		}}, nil
+	})
+}
+
+// Deprecated: use RunWithContext
 func (p *pipeline) Run(name string, options *RunOptions) (string, error) {
+	res, err := p.RunWithContext(context.Background(), name, options)
+	if err != nil {
+		return "", err
+	}
+	return res.ID, nil
+}
+
+// RunWithContext - starts a build of the pipeline
+func (p *pipeline) RunWithContext(ctx context.Context, name string, options *RunOptions) (*RunResult, error) {
 	if options == nil {
 		options = &RunOptions{}
 	}
-	resp, err := p.codefresh.requestAPI(&requestOptions{
+	if len(options.Skip) > 0 && len(options.Only) > 0 {
+		return nil, fmt.Errorf("failed to run pipeline %s: Skip and Only are mutually exclusive", name)
+	}
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   fmt.Sprintf("/api/pipelines/run/%s", url.PathEscape(name)),
 		method: "POST",
-		body: map[string]interface{}{
-			"branch":    options.Branch,
-			"variables": options.Variables,
-		},
+		body:   newRunRequest(options),
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	res, err := p.codefresh.getBodyAsString(resp)
-	return strings.Replace(res, "\"", "", -1), err
+	if err != nil {
+		return nil, err
+	}
+	id := strings.Replace(strings.TrimSpace(res), "\"", "", -1)
+	return &RunResult{
+		ID:  id,
+		URL: fmt.Sprintf("%s/build/%s", p.codefresh.host, id),
+	}, nil
+}
+
+func newRunRequest(options *RunOptions) *runRequest {
+	req := &runRequest{
+		Branch:    options.Branch,
+		Variables: options.Variables,
+		SHA:       options.SHA,
+		Trigger:   options.TriggerID,
+		Contexts:  options.Contexts,
+		Options: runOptionsFlags{
+			NoCache:     options.NoCache,
+			ResetVolume: options.ResetVolume,
+			NoCfCache:   options.NoCfCache,
+		},
+		Skip:               options.Skip,
+		Only:               options.Only,
+		UserYamlDescriptor: options.Yaml,
+		RuntimeEnvironment: options.RuntimeEnvironment,
+		IsDebug:            options.Debug,
+		Priority:           options.Priority,
+	}
+	keys := make([]string, 0, len(options.Annotations))
+	for k := range options.Annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		req.Annotations = append(req.Annotations, Annotation{Key: k, Value: options.Annotations[k]})
+	}
+	return req
+}
+
+// GetWithContext - returns a single pipeline by its full name (project/name) or ID
+func (p *pipeline) GetWithContext(ctx context.Context, nameOrID string) (*Pipeline, error) {
+	return p.get(ctx, nameOrID, nil)
+}
+
+// getDecrypted returns the pipeline with the values of its encrypted variables in clear,
+// for writes that send the whole pipeline back
+func (p *pipeline) getDecrypted(ctx context.Context, nameOrID string) (*Pipeline, error) {
+	return p.get(ctx, nameOrID, map[string]string{"decryptVariables": "true"})
+}
+
+func (p *pipeline) get(ctx context.Context, nameOrID string, qs map[string]string) (*Pipeline, error) {
+	r := &Pipeline{}
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/pipelines/%s", url.PathEscape(nameOrID)),
+		method: "GET",
+		qs:     qs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = p.codefresh.decodeResponseInto(resp, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CreateWithContext - creates a new pipeline, fails with a conflict when the name is taken
+func (p *pipeline) CreateWithContext(ctx context.Context, pipeline *Pipeline) (*Pipeline, error) {
+	r := &Pipeline{}
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   "/api/pipelines",
+		method: "POST",
+		body:   pipeline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = p.codefresh.decodeResponseInto(resp, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UpdateWithContext - replaces the pipeline identified by Metadata.ID (or Metadata.Name when the ID is empty),
+// when Metadata.Revision is set the server rejects the update with a conflict if the pipeline changed meanwhile
+func (p *pipeline) UpdateWithContext(ctx context.Context, pipeline *Pipeline) (*Pipeline, error) {
+	nameOrID := pipeline.Metadata.ID
+	if nameOrID == "" {
+		nameOrID = pipeline.Metadata.Name
+	}
+	if nameOrID == "" {
+		return nil, fmt.Errorf("failed to update pipeline: metadata.id or metadata.name is required")
+	}
+	r := &Pipeline{}
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/pipelines/%s", url.PathEscape(nameOrID)),
+		method: "PUT",
+		body:   pipeline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = p.codefresh.decodeResponseInto(resp, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// PatchWithContext - reads the pipeline, applies mutate and writes it back,
+// starting over from a fresh read when another writer updated it in between
+func (p *pipeline) PatchWithContext(ctx context.Context, nameOrID string, mutate func(*Pipeline) error) (*Pipeline, error) {
+	var err error
+	for attempt := 0; attempt < maxPatchAttempts; attempt++ {
+		var current, updated *Pipeline
+		// read with the encrypted values in clear, writing back their mask would overwrite them
+		current, err = p.getDecrypted(ctx, nameOrID)
+		if err != nil {
+			return nil, err
+		}
+		if err = mutate(current); err != nil {
+			return nil, err
+		}
+		updated, err = p.UpdateWithContext(ctx, current)
+		if err == nil {
+			return updated, nil
+		}
+		if !IsConflict(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("failed to patch pipeline %s after %d attempts: %w", nameOrID, maxPatchAttempts, err)
+}
+
+// DeleteWithContext - deletes the pipeline by its full name or ID
+func (p *pipeline) DeleteWithContext(ctx context.Context, nameOrID string) error {
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/pipelines/%s", url.PathEscape(nameOrID)),
+		method: "DELETE",
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
 }
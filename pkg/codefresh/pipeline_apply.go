@@ -0,0 +1,451 @@
+package codefresh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DiffOp is the kind of a PipelineChange
+type DiffOp string
+
+// ApplyAction is what ApplyWithContext did with the desired pipeline
+type ApplyAction string
+
+const (
+	// secretMask - what the API returns, and diffs show, in place of an encrypted value
+	secretMask = "*****"
+	// changedSecretValue - shown in diffs in place of an encrypted value that changed
+	changedSecretValue = "***** (changed)"
+)
+
+const (
+	DiffAdded   DiffOp = "added"
+	DiffRemoved DiffOp = "removed"
+	DiffChanged DiffOp = "changed"
+	// DiffReordered - the same steps in a different order, Old and New hold the step names
+	DiffReordered DiffOp = "reordered"
+
+	ApplyCreated   ApplyAction = "created"
+	ApplyUpdated   ApplyAction = "updated"
+	ApplyUnchanged ApplyAction = "unchanged"
+)
+
+type (
+	// PipelineChange is a single difference between the live and the desired pipeline
+	PipelineChange struct {
+		// Path - dotted path of the field, e.g. spec.steps.build.image
+		Path string
+		Op   DiffOp
+		Old  interface{}
+		New  interface{}
+	}
+
+	// PipelineDiff lists the changes needed to turn the live pipeline into the desired one
+	PipelineDiff []PipelineChange
+
+	// ApplyResult is returned by ApplyWithContext
+	ApplyResult struct {
+		Action ApplyAction
+		// Pipeline - the pipeline as stored by the server
+		Pipeline *Pipeline
+		Diff     PipelineDiff
+	}
+)
+
+// DiffWithContext - compares the desired pipeline with the live one, a pipeline that does not exist yet is compared with an empty one
+func (p *pipeline) DiffWithContext(ctx context.Context, desired *Pipeline) (PipelineDiff, error) {
+	_, _, diff, err := p.diffLive(ctx, desired)
+	return diff, err
+}
+
+// ApplyWithContext - creates the desired pipeline or updates the live one when they differ,
+// server managed fields of desired (ID, AccountID, CreatedAt, UpdatedAt, Revision) are ignored,
+// masked or empty encrypted values of desired keep the value stored by the server
+func (p *pipeline) ApplyWithContext(ctx context.Context, desired *Pipeline) (*ApplyResult, error) {
+	live, compared, diff, err := p.diffLive(ctx, desired)
+	if err != nil {
+		return nil, err
+	}
+	target := copyPipeline(desired)
+	clearServerFields(target)
+	if live == nil {
+		if masked := maskedVariables(target); len(masked) > 0 {
+			return nil, fmt.Errorf("failed to create pipeline: encrypted variables %s have no value", strings.Join(masked, ", "))
+		}
+		created, err := p.CreateWithContext(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		return &ApplyResult{Action: ApplyCreated, Pipeline: created, Diff: diff}, nil
+	}
+	if len(diff) == 0 {
+		return &ApplyResult{Action: ApplyUnchanged, Pipeline: live, Diff: diff}, nil
+	}
+	target = reconcileWithLive(target, compared)
+	if len(maskedVariables(target)) > 0 {
+		// sending the mask back would overwrite the stored secret with it
+		decrypted, err := p.getDecrypted(ctx, live.Metadata.ID)
+		if err != nil {
+			return nil, err
+		}
+		target = reconcileWithLive(target, decrypted)
+		if masked := maskedVariables(target); len(masked) > 0 {
+			return nil, fmt.Errorf("failed to update pipeline: the values of encrypted variables %s could not be read", strings.Join(masked, ", "))
+		}
+	}
+	target.Metadata.ID = live.Metadata.ID
+	target.Metadata.Revision = live.Metadata.Revision
+	updated, err := p.UpdateWithContext(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	return &ApplyResult{Action: ApplyUpdated, Pipeline: updated, Diff: diff}, nil
+}
+
+// diffLive fetches the live pipeline, nil when it does not exist, and diffs it with desired.
+// It also returns the pipeline desired was compared with, the live one with its encrypted values
+// in clear when desired sets new ones
+func (p *pipeline) diffLive(ctx context.Context, desired *Pipeline) (*Pipeline, *Pipeline, PipelineDiff, error) {
+	name := desired.Metadata.Name
+	if name == "" {
+		return nil, nil, nil, fmt.Errorf("failed to diff pipeline: metadata.name is required")
+	}
+	live, err := p.GetWithContext(ctx, name)
+	if err != nil && !IsNotFound(err) {
+		return nil, nil, nil, err
+	}
+	compared := live
+	if compared == nil {
+		compared = &Pipeline{}
+	} else if setsEncryptedValues(desired, live) {
+		decrypted, err := p.getDecrypted(ctx, live.Metadata.ID)
+		apiErr := &APIError{}
+		switch {
+		case err == nil:
+			compared = decrypted
+		case !errors.As(err, &apiErr):
+			return nil, nil, nil, err
+		}
+		// without the right to read them, the values set by desired are reported as changed
+	}
+	diff, err := DiffPipelines(compared, desired)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return live, compared, diff, nil
+}
+
+// setsEncryptedValues reports whether desired has values for encrypted variables that live only has masked
+func setsEncryptedValues(desired *Pipeline, live *Pipeline) bool {
+	found := false
+	eachVariablePair(desired, live, func(d *Variable, l *Variable) {
+		if d.Encrypted && d.Value != "" && !isMaskedValue(d.Value) && isMaskedValue(l.Value) {
+			found = true
+		}
+	})
+	return found
+}
+
+// DiffPipelines returns the structural changes from live to desired, ignoring server managed fields,
+// what the server fills in that desired leaves out and encrypted values desired has masked.
+// Encrypted values are never shown, a value that differs from a masked live one is always reported as changed
+func DiffPipelines(live *Pipeline, desired *Pipeline) (PipelineDiff, error) {
+	base := copyPipeline(live)
+	compared := reconcileWithLive(desired, live)
+	eachVariablePair(compared, base, func(d *Variable, l *Variable) {
+		if d.Encrypted && d.Value != l.Value {
+			d.Value = changedSecretValue
+		}
+	})
+	redactSecrets(base)
+	redactSecrets(compared)
+	from, err := normalizePipeline(base)
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalizePipeline(compared)
+	if err != nil {
+		return nil, err
+	}
+	diff := PipelineDiff{}
+	diffValues("", from, to, &diff)
+	return diff, nil
+}
+
+// normalizePipeline returns the ordered generic form of the pipeline without server managed fields
+func normalizePipeline(pl *Pipeline) (interface{}, error) {
+	out := copyPipeline(pl)
+	clearServerFields(out)
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return readJSONAsYAMLValue(dec)
+}
+
+func clearServerFields(pl *Pipeline) {
+	pl.Metadata.ID = ""
+	pl.Metadata.AccountID = ""
+	pl.Metadata.CreatedAt = time.Time{}
+	pl.Metadata.UpdatedAt = time.Time{}
+	pl.Metadata.Revision = 0
+}
+
+// isMaskedValue reports whether value is the mask the API returns in place of an encrypted value
+func isMaskedValue(value string) bool {
+	return value != "" && strings.Trim(value, "*") == ""
+}
+
+// redactSecrets replaces the values of the encrypted variables of pl with the mask, except changed markers
+func redactSecrets(pl *Pipeline) {
+	redact := func(vars []Variable) {
+		for i := range vars {
+			if vars[i].Encrypted && vars[i].Value != changedSecretValue {
+				vars[i].Value = secretMask
+			}
+		}
+	}
+	redact(pl.Spec.Variables)
+	for i := range pl.Spec.Triggers {
+		redact(pl.Spec.Triggers[i].Variables)
+	}
+	for i := range pl.Spec.CronTriggers {
+		redact(pl.Spec.CronTriggers[i].Variables)
+	}
+}
+
+// reconcileWithLive returns a copy of desired completed with what only the server knows:
+// metadata fields desired does not set, the IDs and the disabled state of the triggers,
+// matched by name, and the values of encrypted variables desired has masked or empty
+func reconcileWithLive(desired *Pipeline, live *Pipeline) *Pipeline {
+	out := copyPipeline(desired)
+	if out.Metadata.Project == "" {
+		out.Metadata.Project = live.Metadata.Project
+	}
+	if out.Metadata.Deprecate.ApplicationPort == "" && !out.Metadata.Deprecate.RepoPipeline {
+		out.Metadata.Deprecate = live.Metadata.Deprecate
+	}
+	for k, v := range live.Metadata.Extra {
+		if _, ok := out.Metadata.Extra[k]; !ok {
+			if out.Metadata.Extra == nil {
+				out.Metadata.Extra = map[string]json.RawMessage{}
+			}
+			out.Metadata.Extra[k] = v
+		}
+	}
+	for i := range out.Spec.Triggers {
+		trigger := &out.Spec.Triggers[i]
+		for _, l := range live.Spec.Triggers {
+			if l.Name != trigger.Name {
+				continue
+			}
+			if trigger.ID == "" {
+				trigger.ID = l.ID
+			}
+			if trigger.Disabled == nil {
+				trigger.Disabled = l.Disabled
+			}
+		}
+	}
+	for i := range out.Spec.CronTriggers {
+		cron := &out.Spec.CronTriggers[i]
+		for _, l := range live.Spec.CronTriggers {
+			if l.Name != cron.Name {
+				continue
+			}
+			if cron.GitTriggerID == "" {
+				cron.GitTriggerID = l.GitTriggerID
+			}
+			if cron.Disabled == nil {
+				cron.Disabled = l.Disabled
+			}
+		}
+	}
+	eachVariablePair(out, live, func(d *Variable, l *Variable) {
+		if d.Encrypted && (d.Value == "" || isMaskedValue(d.Value)) {
+			d.Value = l.Value
+		}
+	})
+	return out
+}
+
+// eachVariablePair calls fn with every variable of desired and the live variable of the same scope and key
+func eachVariablePair(desired *Pipeline, live *Pipeline, fn func(desired *Variable, live *Variable)) {
+	pair := func(vars []Variable, liveVars []Variable) {
+		for i := range vars {
+			for j := range liveVars {
+				if liveVars[j].Key == vars[i].Key {
+					fn(&vars[i], &liveVars[j])
+					break
+				}
+			}
+		}
+	}
+	pair(desired.Spec.Variables, live.Spec.Variables)
+	for i := range desired.Spec.Triggers {
+		for _, l := range live.Spec.Triggers {
+			if l.Name == desired.Spec.Triggers[i].Name {
+				pair(desired.Spec.Triggers[i].Variables, l.Variables)
+			}
+		}
+	}
+	for i := range desired.Spec.CronTriggers {
+		for _, l := range live.Spec.CronTriggers {
+			if l.Name == desired.Spec.CronTriggers[i].Name {
+				pair(desired.Spec.CronTriggers[i].Variables, l.Variables)
+			}
+		}
+	}
+}
+
+// maskedVariables returns the paths of the encrypted variables of pl that only have the mask as value
+func maskedVariables(pl *Pipeline) []string {
+	masked := []string{}
+	collect := func(scope string, vars []Variable) {
+		for _, v := range vars {
+			if v.Encrypted && isMaskedValue(v.Value) {
+				masked = append(masked, scope+v.Key)
+			}
+		}
+	}
+	collect("spec.variables.", pl.Spec.Variables)
+	for _, t := range pl.Spec.Triggers {
+		collect("spec.triggers."+t.Name+".variables.", t.Variables)
+	}
+	for _, c := range pl.Spec.CronTriggers {
+		collect("spec.cronTriggers."+c.Name+".variables.", c.Variables)
+	}
+	return masked
+}
+
+func diffValues(path string, from interface{}, to interface{}, diff *PipelineDiff) {
+	switch f := from.(type) {
+	case yaml.MapSlice:
+		if t, ok := to.(yaml.MapSlice); ok {
+			diffObjects(path, f, t, diff)
+			return
+		}
+	case []interface{}:
+		if t, ok := to.([]interface{}); ok {
+			diffLists(path, f, t, diff)
+			return
+		}
+	}
+	if !valuesEqual(from, to) {
+		*diff = append(*diff, PipelineChange{Path: path, Op: DiffChanged, Old: from, New: to})
+	}
+}
+
+func diffObjects(path string, from yaml.MapSlice, to yaml.MapSlice, diff *PipelineDiff) {
+	fromKeys, toKeys := mapSliceIndex(from), mapSliceIndex(to)
+	sameKeys := len(fromKeys) == len(toKeys)
+	for _, item := range from {
+		key := fmt.Sprint(item.Key)
+		if _, ok := toKeys[key]; !ok {
+			sameKeys = false
+			*diff = append(*diff, PipelineChange{Path: joinDiffPath(path, key), Op: DiffRemoved, Old: item.Value})
+		}
+	}
+	for _, item := range to {
+		key := fmt.Sprint(item.Key)
+		old, ok := fromKeys[key]
+		if !ok {
+			*diff = append(*diff, PipelineChange{Path: joinDiffPath(path, key), Op: DiffAdded, New: item.Value})
+			continue
+		}
+		diffValues(joinDiffPath(path, key), old, item.Value, diff)
+	}
+	// only the order of steps is meaningful
+	if sameKeys && isStepsPath(path) {
+		oldOrder, newOrder := mapSliceKeys(from), mapSliceKeys(to)
+		if strings.Join(oldOrder, "\n") != strings.Join(newOrder, "\n") {
+			*diff = append(*diff, PipelineChange{Path: path, Op: DiffReordered, Old: oldOrder, New: newOrder})
+		}
+	}
+}
+
+func isStepsPath(path string) bool {
+	return (path == "steps" || strings.HasSuffix(path, ".steps")) && !strings.Contains(path, ".when.")
+}
+
+func diffLists(path string, from []interface{}, to []interface{}, diff *PipelineDiff) {
+	for i := 0; i < len(from) || i < len(to); i++ {
+		itemPath := joinDiffPath(path, fmt.Sprint(i))
+		switch {
+		case i >= len(to):
+			*diff = append(*diff, PipelineChange{Path: itemPath, Op: DiffRemoved, Old: from[i]})
+		case i >= len(from):
+			*diff = append(*diff, PipelineChange{Path: itemPath, Op: DiffAdded, New: to[i]})
+		default:
+			diffValues(itemPath, from[i], to[i], diff)
+		}
+	}
+}
+
+func mapSliceIndex(m yaml.MapSlice) map[string]interface{} {
+	index := make(map[string]interface{}, len(m))
+	for _, item := range m {
+		index[fmt.Sprint(item.Key)] = item.Value
+	}
+	return index
+}
+
+func mapSliceKeys(m yaml.MapSlice) []string {
+	keys := make([]string, 0, len(m))
+	for _, item := range m {
+		keys = append(keys, fmt.Sprint(item.Key))
+	}
+	return keys
+}
+
+func valuesEqual(a interface{}, b interface{}) bool {
+	return formatDiffValue(a) == formatDiffValue(b)
+}
+
+func joinDiffPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// formatDiffValue renders a value of the generic form as compact JSON
+func formatDiffValue(v interface{}) string {
+	if keys, ok := v.([]string); ok {
+		return strings.Join(keys, ", ")
+	}
+	buf := &bytes.Buffer{}
+	if err := writeYAMLValueAsJSON(buf, v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return buf.String()
+}
+
+func (c PipelineChange) String() string {
+	switch c.Op {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, formatDiffValue(c.New))
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, formatDiffValue(c.Old))
+	case DiffReordered:
+		return fmt.Sprintf("~ %s: order %s -> %s", c.Path, formatDiffValue(c.Old), formatDiffValue(c.New))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", c.Path, formatDiffValue(c.Old), formatDiffValue(c.New))
+}
+
+func (d PipelineDiff) String() string {
+	lines := make([]string, len(d))
+	for i, c := range d {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
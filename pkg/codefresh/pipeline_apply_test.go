@@ -0,0 +1,175 @@
+package codefresh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffPipelines(t *testing.T) {
+	live := &Pipeline{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"metadata": {"id": "5f1d", "name": "proj/build", "revision": 3, "created_at": "2020-01-01T00:00:00Z"},
+		"spec": {"steps": {"a": {"image": "alpine"}, "b": {"image": "node"}}, "contexts": ["x"]}
+	}`), live))
+	desired := &Pipeline{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"metadata": {"name": "proj/build"},
+		"spec": {"steps": {"b": {"image": "node"}, "a": {"image": "alpine:3"}}, "variables": [{"key": "A", "value": "1"}]}
+	}`), desired))
+
+	diff, err := DiffPipelines(live, desired)
+	assert.NoError(t, err)
+	assert.Equal(t, `- spec.contexts: ["x"]
++ spec.variables: [{"key":"A","value":"1"}]
+~ spec.steps.a.image: "alpine" -> "alpine:3"
+~ spec.steps: order a, b -> b, a`, diff.String())
+
+	diff, err = DiffPipelines(live, live)
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+
+	// without the decrypted live value a new secret is always reported as changed
+	live.Spec.Variables = []Variable{{Key: "TOKEN", Value: "*****", Encrypted: true}}
+	rotated := copyPipeline(live)
+	rotated.Spec.Variables[0].Value = "new-secret"
+	diff, err = DiffPipelines(live, rotated)
+	assert.NoError(t, err)
+	assert.Equal(t, `~ spec.variables.0.value: "*****" -> "***** (changed)"`, diff.String())
+}
+
+func TestPipelineApply(t *testing.T) {
+	stored := ""
+	puts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if stored == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(stored))
+		case "POST", "PUT":
+			body := &Pipeline{}
+			json.NewDecoder(r.Body).Decode(body)
+			if r.Method == "PUT" {
+				puts++
+				assert.Equal(t, "5f1d", body.Metadata.ID)
+				assert.Equal(t, 1, body.Metadata.Revision)
+			}
+			body.Metadata.ID = "5f1d"
+			body.Metadata.Revision++
+			data, _ := json.Marshal(body)
+			stored = string(data)
+			w.Write(data)
+		}
+	}))
+	defer server.Close()
+	cf := New(&ClientOptions{Host: server.URL})
+
+	desired := &Pipeline{}
+	desired.Metadata.Name = "proj/build"
+	desired.Spec.Steps = Steps{{Name: "a", Image: "alpine"}}
+
+	res, err := cf.Pipelines().ApplyWithContext(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyCreated, res.Action)
+	assert.Equal(t, "5f1d", res.Pipeline.Metadata.ID)
+
+	res, err = cf.Pipelines().ApplyWithContext(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyUnchanged, res.Action)
+	assert.Empty(t, res.Diff)
+
+	desired.Spec.Steps[0].Image = "alpine:3"
+	diff, err := cf.Pipelines().DiffWithContext(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Len(t, diff, 1)
+
+	res, err = cf.Pipelines().ApplyWithContext(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyUpdated, res.Action)
+	assert.Equal(t, "spec.steps.a.image", res.Diff[0].Path)
+	assert.Equal(t, 1, puts)
+	assert.Equal(t, "", desired.Metadata.ID)
+}
+
+func TestPipelineApplyServerAssignedFields(t *testing.T) {
+	// the server assigns trigger ids and masks encrypted values unless asked to decrypt them
+	decryptable := true
+	stored := &Pipeline{}
+	var put *Pipeline
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			out := copyPipeline(stored)
+			if !decryptable || r.URL.Query().Get("decryptVariables") != "true" {
+				out.Spec.Variables[0].Value = "*****"
+			}
+			json.NewEncoder(w).Encode(out)
+		case "PUT":
+			put = &Pipeline{}
+			json.NewDecoder(r.Body).Decode(put)
+			stored = copyPipeline(put)
+			json.NewEncoder(w).Encode(put)
+		}
+	}))
+	defer server.Close()
+	cf := New(&ClientOptions{Host: server.URL})
+
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"metadata": {"id": "5f1d", "name": "proj/build", "project": "proj", "revision": 1, "shortLink": "cf.io/x"},
+		"spec": {
+			"triggers": [{"id": "t-1", "name": "push", "repo": "org/repo"}],
+			"variables": [{"key": "TOKEN", "value": "s3cr3t", "encrypted": true}, {"key": "A", "value": "1"}],
+			"steps": {"a": {"image": "alpine"}}
+		}
+	}`), stored))
+	desired := &Pipeline{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"metadata": {"name": "proj/build"},
+		"spec": {
+			"triggers": [{"name": "push", "repo": "org/repo"}],
+			"variables": [{"key": "TOKEN", "value": "*****", "encrypted": true}, {"key": "A", "value": "1"}],
+			"steps": {"a": {"image": "alpine"}}
+		}
+	}`), desired))
+
+	res, err := cf.Pipelines().ApplyWithContext(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyUnchanged, res.Action)
+	assert.Nil(t, put)
+
+	desired.Spec.Variables[1].Value = "2"
+	res, err = cf.Pipelines().ApplyWithContext(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyUpdated, res.Action)
+	assert.Equal(t, "spec.variables.1.value", res.Diff[0].Path)
+	assert.Len(t, res.Diff, 1)
+	assert.Equal(t, "s3cr3t", put.Spec.Variables[0].Value)
+	assert.Equal(t, "t-1", put.Spec.Triggers[0].ID)
+	assert.Equal(t, `"cf.io/x"`, string(put.Metadata.Extra["shortLink"]))
+	assert.Equal(t, "proj", put.Metadata.Project)
+
+	// a new value for an encrypted variable is compared with the decrypted one and never shown
+	desired.Spec.Variables[0].Value = "new-secret"
+	res, err = cf.Pipelines().ApplyWithContext(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyUpdated, res.Action)
+	assert.Equal(t, `~ spec.variables.0.value: "*****" -> "***** (changed)"`, res.Diff.String())
+	assert.Equal(t, "new-secret", put.Spec.Variables[0].Value)
+
+	res, err = cf.Pipelines().ApplyWithContext(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyUnchanged, res.Action)
+	desired.Spec.Variables[0].Value = ""
+
+	decryptable = false
+	desired.Spec.Variables[1].Value = "3"
+	_, err = cf.Pipelines().ApplyWithContext(context.Background(), desired)
+	assert.EqualError(t, err, "failed to update pipeline: the values of encrypted variables spec.variables.TOKEN could not be read")
+}
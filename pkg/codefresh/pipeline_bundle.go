@@ -0,0 +1,592 @@
+package codefresh
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+	// v3 for the positions of its nodes, see remapYAMLContexts
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// BundleVersion is the version of the bundle format written by ExportWithContext
+const BundleVersion = "1"
+
+const bundleManifestFile = "bundle.yaml"
+
+var secretPlaceholder = regexp.MustCompile(`^<secret:(.+)>$`)
+
+type (
+	// Bundle is a portable set of pipelines, free of account specific fields
+	Bundle struct {
+		Version    string
+		ExportedAt time.Time
+		Pipelines  []*Pipeline
+	}
+
+	// ExportOptions selects the pipelines to export, both fields can be combined
+	ExportOptions struct {
+		// Pipelines - full names (project/name) or IDs
+		Pipelines []string
+		// Project - export every pipeline of the project
+		Project string
+	}
+
+	// ImportOptions - how the pipelines of a bundle are created in the target account
+	ImportOptions struct {
+		// ProjectMap - source project name to target project name
+		ProjectMap map[string]string
+		// ContextMap - source context name to target context name, applies to shared and git contexts
+		ContextMap map[string]string
+		// RuntimeEnvironmentMap - source runtime environment name to target name
+		RuntimeEnvironmentMap map[string]string
+		// Secrets - values of the encrypted variables, keyed by the reference in their placeholder
+		Secrets map[string]string
+		// Overwrite - update pipelines that already exist instead of failing
+		Overwrite bool
+	}
+
+	// ImportResult lists the full names of the imported pipelines
+	ImportResult struct {
+		Created []string
+		Updated []string
+	}
+
+	bundleManifest struct {
+		Version    string    `yaml:"version"`
+		ExportedAt time.Time `yaml:"exportedAt"`
+		Pipelines  []string  `yaml:"pipelines"`
+	}
+)
+
+// SecretPlaceholder returns the value ExportWithContext puts in place of an encrypted variable
+func SecretPlaceholder(ref string) string {
+	return fmt.Sprintf("<secret:%s>", ref)
+}
+
+// ExportWithContext - fetches the selected pipelines and strips the account specific fields,
+// values of encrypted variables are replaced with placeholders
+func (p *pipeline) ExportWithContext(ctx context.Context, opt *ExportOptions) (*Bundle, error) {
+	if opt == nil || (len(opt.Pipelines) == 0 && opt.Project == "") {
+		return nil, fmt.Errorf("failed to export pipelines: no pipelines or project selected")
+	}
+	bundle := &Bundle{Version: BundleVersion, ExportedAt: time.Now().UTC()}
+	seen := map[string]bool{}
+	add := func(pl *Pipeline) {
+		if seen[pl.Metadata.Name] {
+			return
+		}
+		seen[pl.Metadata.Name] = true
+		bundle.Pipelines = append(bundle.Pipelines, exportPipeline(pl))
+	}
+	for _, name := range opt.Pipelines {
+		pl, err := p.GetWithContext(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export pipeline %s: %w", name, err)
+		}
+		add(pl)
+	}
+	if opt.Project != "" {
+		err := p.ForEachWithContext(ctx, &PipelineListOptions{Name: "^" + regexp.QuoteMeta(opt.Project+"/")}, func(pl *Pipeline) error {
+			if pl.Metadata.Project == opt.Project {
+				add(pl)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export project %s: %w", opt.Project, err)
+		}
+	}
+	return bundle, nil
+}
+
+// ImportWithContext - creates the pipelines of the bundle, remapping projects, contexts and runtime environments by name
+func (p *pipeline) ImportWithContext(ctx context.Context, bundle *Bundle, opt *ImportOptions) (*ImportResult, error) {
+	if bundle.Version != BundleVersion {
+		return nil, fmt.Errorf("failed to import bundle: unsupported version %q", bundle.Version)
+	}
+	if opt == nil {
+		opt = &ImportOptions{}
+	}
+	pipelines := make([]*Pipeline, 0, len(bundle.Pipelines))
+	missing := []string{}
+	for _, pl := range bundle.Pipelines {
+		imported, refs := importPipeline(pl, opt)
+		missing = append(missing, refs...)
+		pipelines = append(pipelines, imported)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("failed to import bundle: missing secrets %s", strings.Join(missing, ", "))
+	}
+
+	// every name is checked before anything is written so a collision does not leave a partial import
+	lives := make([]*Pipeline, len(pipelines))
+	existing := []string{}
+	for i, pl := range pipelines {
+		live, err := p.GetWithContext(ctx, pl.Metadata.Name)
+		if err != nil && !IsNotFound(err) {
+			return nil, fmt.Errorf("failed to import pipeline %s: %w", pl.Metadata.Name, err)
+		}
+		if live != nil {
+			existing = append(existing, pl.Metadata.Name)
+		}
+		lives[i] = live
+	}
+	if len(existing) > 0 && !opt.Overwrite {
+		return nil, fmt.Errorf("failed to import bundle: pipelines already exist %s", strings.Join(existing, ", "))
+	}
+
+	result := &ImportResult{}
+	for i, pl := range pipelines {
+		name := pl.Metadata.Name
+		links := cronTriggerLinks(bundle.Pipelines[i])
+		var saved *Pipeline
+		var err error
+		if live := lives[i]; live == nil {
+			saved, err = p.CreateWithContext(ctx, pl)
+			if err == nil {
+				result.Created = append(result.Created, name)
+			}
+		} else {
+			pl.Metadata.ID = live.Metadata.ID
+			pl.Metadata.Revision = live.Metadata.Revision
+			saved, err = p.UpdateWithContext(ctx, pl)
+			if err == nil {
+				result.Updated = append(result.Updated, name)
+			}
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to import pipeline %s: %w", name, err)
+		}
+		// the IDs of the git triggers are only known once the server has assigned them
+		if linkCronTriggers(saved, links) {
+			if _, err := p.UpdateWithContext(ctx, saved); err != nil {
+				return result, fmt.Errorf("failed to import pipeline %s: %w", name, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// exportPipeline returns a copy of pl without server managed fields and secret values
+func exportPipeline(pl *Pipeline) *Pipeline {
+	out := copyPipeline(pl)
+	out.Metadata.ID = ""
+	out.Metadata.AccountID = ""
+	out.Metadata.CreatedAt = time.Time{}
+	out.Metadata.UpdatedAt = time.Time{}
+	out.Metadata.Revision = 0
+	out.Metadata.Extra = withoutAccountScoped(out.Metadata.Extra)
+
+	name := out.Metadata.Name
+	maskSecrets(out.Spec.Variables, name)
+	for i := range out.Spec.Triggers {
+		maskSecrets(out.Spec.Triggers[i].Variables, name+"/triggers/"+out.Spec.Triggers[i].Name)
+	}
+	for i := range out.Spec.CronTriggers {
+		maskSecrets(out.Spec.CronTriggers[i].Variables, name+"/cronTriggers/"+out.Spec.CronTriggers[i].Name)
+	}
+	return out
+}
+
+// importPipeline returns a remapped copy of pl and the secret references missing from opt.Secrets
+func importPipeline(pl *Pipeline, opt *ImportOptions) (*Pipeline, []string) {
+	out := copyPipeline(pl)
+	if project, ok := opt.ProjectMap[out.Metadata.Project]; ok {
+		base := strings.TrimPrefix(out.Metadata.Name, out.Metadata.Project+"/")
+		out.Metadata.Name = path.Join(project, base)
+		out.Metadata.Project = project
+	}
+
+	// bundles written before the keys were stripped on export may still carry them
+	out.Metadata.Extra = withoutAccountScoped(out.Metadata.Extra)
+	out.Metadata.OriginalYamlString = remapYAMLContexts(out.Metadata.OriginalYamlString, opt.ContextMap)
+
+	spec := &out.Spec
+	spec.Contexts = remapNames(spec.Contexts, opt.ContextMap)
+	remapRuntimeEnvironment(spec.RuntimeEnvironment, opt.RuntimeEnvironmentMap)
+	remapStepContexts(spec.Steps, opt.ContextMap)
+	remapHookContexts(spec.Hooks, opt.ContextMap)
+	missing := resolveSecrets(spec.Variables, opt.Secrets)
+	for i := range spec.Triggers {
+		trigger := &spec.Triggers[i]
+		// trigger IDs belong to the source account, the server assigns new ones
+		trigger.ID = ""
+		if name, ok := opt.ContextMap[trigger.Context]; ok {
+			trigger.Context = name
+		}
+		trigger.Contexts = remapNames(trigger.Contexts, opt.ContextMap)
+		remapRuntimeEnvironment(trigger.RuntimeEnvironment, opt.RuntimeEnvironmentMap)
+		missing = append(missing, resolveSecrets(trigger.Variables, opt.Secrets)...)
+	}
+	for i := range spec.CronTriggers {
+		// relinked by linkCronTriggers once the git triggers exist
+		spec.CronTriggers[i].GitTriggerID = ""
+		missing = append(missing, resolveSecrets(spec.CronTriggers[i].Variables, opt.Secrets)...)
+	}
+	return out, missing
+}
+
+// withoutAccountScoped drops the metadata keys that reference objects of the source account,
+// such as projectId, they are assigned again by the target account
+func withoutAccountScoped(extra map[string]json.RawMessage) map[string]json.RawMessage {
+	out := map[string]json.RawMessage{}
+	for k, v := range extra {
+		if !strings.HasSuffix(k, "Id") && !strings.HasSuffix(k, "Ids") {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// cronTriggerLinks returns the name of the git trigger each cron trigger of pl uses, by cron trigger name
+func cronTriggerLinks(pl *Pipeline) map[string]string {
+	links := map[string]string{}
+	for _, cron := range pl.Spec.CronTriggers {
+		for _, trigger := range pl.Spec.Triggers {
+			if cron.GitTriggerID != "" && trigger.ID == cron.GitTriggerID {
+				links[cron.Name] = trigger.Name
+			}
+		}
+	}
+	return links
+}
+
+// linkCronTriggers points the cron triggers of pl at the IDs of their git triggers, it reports whether any changed
+func linkCronTriggers(pl *Pipeline, links map[string]string) bool {
+	changed := false
+	for i := range pl.Spec.CronTriggers {
+		cron := &pl.Spec.CronTriggers[i]
+		for _, trigger := range pl.Spec.Triggers {
+			if links[cron.Name] == trigger.Name && trigger.ID != "" && cron.GitTriggerID != trigger.ID {
+				cron.GitTriggerID = trigger.ID
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// stepContextKeys are the step fields that name a git or registry context
+var stepContextKeys = []string{"git", "registry", "registry_context"}
+
+func remapStepContexts(steps Steps, mapping map[string]string) {
+	for _, step := range steps {
+		if name, ok := mapping[step.Git]; ok {
+			step.Git = name
+		}
+		if name, ok := mapping[step.Registry]; ok {
+			step.Registry = name
+		}
+		if raw, ok := step.Extra["registry_context"]; ok {
+			var context string
+			if json.Unmarshal(raw, &context) == nil {
+				if name, ok := mapping[context]; ok {
+					step.Extra["registry_context"], _ = json.Marshal(name)
+				}
+			}
+		}
+		remapStepContexts(step.Steps, mapping)
+		remapHookContexts(step.Hooks, mapping)
+	}
+}
+
+func remapHookContexts(hooks *Hooks, mapping map[string]string) {
+	if hooks == nil {
+		return
+	}
+	for _, hook := range []*Hook{hooks.OnElected, hooks.OnSuccess, hooks.OnFail, hooks.OnFinish} {
+		if hook != nil {
+			remapStepContexts(hook.Steps, mapping)
+		}
+	}
+}
+
+// remapYAMLContexts rewrites the context names of the git and registry fields of the steps in place,
+// keeping the formatting and comments of the original YAML, content that does not parse is kept as is
+func remapYAMLContexts(data string, mapping map[string]string) string {
+	if len(mapping) == 0 || data == "" {
+		return data
+	}
+	root := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal([]byte(data), root); err != nil || len(root.Content) == 0 {
+		return data
+	}
+	lines := strings.SplitAfter(data, "\n")
+	remap := func(value *yamlv3.Node) {
+		name, ok := mapping[value.Value]
+		if !ok || value.Line < 1 || value.Line > len(lines) {
+			return
+		}
+		line := []rune(lines[value.Line-1])
+		at := value.Column - 1
+		old := []rune(value.Value)
+		if value.Style == yamlv3.DoubleQuotedStyle || value.Style == yamlv3.SingleQuotedStyle {
+			at++
+		}
+		// only plain and simply quoted values are rewritten, the node must match the source text
+		if at < 0 || at+len(old) > len(line) || string(line[at:at+len(old)]) != value.Value {
+			return
+		}
+		lines[value.Line-1] = string(line[:at]) + name + string(line[at+len(old):])
+	}
+	remapStepNodes(yamlMappingValue(root.Content[0], "steps"), remap)
+	remapHookNodes(yamlMappingValue(root.Content[0], "hooks"), remap)
+	return strings.Join(lines, "")
+}
+
+// remapStepNodes calls fn with the value node of every context field of the steps, nested and hook steps included
+func remapStepNodes(steps *yamlv3.Node, fn func(value *yamlv3.Node)) {
+	if steps == nil || steps.Kind != yamlv3.MappingNode {
+		return
+	}
+	for i := 1; i < len(steps.Content); i += 2 {
+		step := steps.Content[i]
+		for _, key := range stepContextKeys {
+			if value := yamlMappingValue(step, key); value != nil && value.Kind == yamlv3.ScalarNode {
+				fn(value)
+			}
+		}
+		remapStepNodes(yamlMappingValue(step, "steps"), fn)
+		remapHookNodes(yamlMappingValue(step, "hooks"), fn)
+	}
+}
+
+func remapHookNodes(hooks *yamlv3.Node, fn func(value *yamlv3.Node)) {
+	if hooks == nil || hooks.Kind != yamlv3.MappingNode {
+		return
+	}
+	for i := 1; i < len(hooks.Content); i += 2 {
+		remapStepNodes(yamlMappingValue(hooks.Content[i], "steps"), fn)
+	}
+}
+
+// yamlMappingValue returns the value of key in a mapping node, nil when absent
+func yamlMappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// copyPipeline deep copies a pipeline by going through its JSON form
+func copyPipeline(pl *Pipeline) *Pipeline {
+	data, _ := json.Marshal(pl)
+	out := &Pipeline{}
+	json.Unmarshal(data, out)
+	return out
+}
+
+func maskSecrets(vars []Variable, scope string) {
+	for i := range vars {
+		if vars[i].Encrypted {
+			vars[i].Value = SecretPlaceholder(scope + "/" + vars[i].Key)
+		}
+	}
+}
+
+func resolveSecrets(vars []Variable, secrets map[string]string) []string {
+	missing := []string{}
+	for i := range vars {
+		m := secretPlaceholder.FindStringSubmatch(vars[i].Value)
+		if m == nil {
+			continue
+		}
+		value, ok := secrets[m[1]]
+		if !ok {
+			missing = append(missing, m[1])
+			continue
+		}
+		vars[i].Value = value
+	}
+	return missing
+}
+
+func remapNames(names []string, mapping map[string]string) []string {
+	for i, name := range names {
+		if mapped, ok := mapping[name]; ok {
+			names[i] = mapped
+		}
+	}
+	return names
+}
+
+func remapRuntimeEnvironment(re *RuntimeEnvironmentOverride, mapping map[string]string) {
+	if re == nil {
+		return
+	}
+	if name, ok := mapping[re.Name]; ok {
+		re.Name = name
+	}
+}
+
+// files returns the bundle layout: a manifest and one YAML file per pipeline under pipelines/
+func (b *Bundle) files() (map[string][]byte, []string, error) {
+	files := map[string][]byte{}
+	manifest := bundleManifest{Version: b.Version, ExportedAt: b.ExportedAt}
+	for _, pl := range b.Pipelines {
+		name := "pipelines/" + pl.Metadata.Name + ".yaml"
+		if err := checkBundlePath(name); err != nil {
+			return nil, nil, err
+		}
+		data, err := yaml.Marshal(pl)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode pipeline %s: %w", pl.Metadata.Name, err)
+		}
+		files[name] = data
+		manifest.Pipelines = append(manifest.Pipelines, name)
+	}
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	files[bundleManifestFile] = data
+	return files, append([]string{bundleManifestFile}, manifest.Pipelines...), nil
+}
+
+// bundleFromFiles reads a bundle back using the files listed by the manifest
+func bundleFromFiles(read func(name string) ([]byte, error)) (*Bundle, error) {
+	data, err := read(bundleManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle manifest: %w", err)
+	}
+	manifest := bundleManifest{}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle manifest: %w", err)
+	}
+	if manifest.Version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %q", manifest.Version)
+	}
+	bundle := &Bundle{Version: manifest.Version, ExportedAt: manifest.ExportedAt}
+	for _, name := range manifest.Pipelines {
+		if err := checkBundlePath(name); err != nil {
+			return nil, err
+		}
+		data, err := read(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		pl := &Pipeline{}
+		if err := yaml.Unmarshal(data, pl); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		bundle.Pipelines = append(bundle.Pipelines, pl)
+	}
+	return bundle, nil
+}
+
+// WriteDir writes the bundle into dir, creating it when needed
+func (b *Bundle) WriteDir(dir string) error {
+	files, names, err := b.files()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(target, files[name], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadBundleDir reads a bundle written by WriteDir
+func ReadBundleDir(dir string) (*Bundle, error) {
+	return bundleFromFiles(func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+}
+
+// WriteTar writes the bundle as a gzipped tarball with the same layout as WriteDir
+func (b *Bundle) WriteTar(w io.Writer) error {
+	files, names, err := b.files()
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(files[name])),
+			ModTime: b.ExportedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadBundleTar reads a bundle written by WriteTar
+func ReadBundleTar(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gz.Close()
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		files[path.Clean(header.Name)] = data
+	}
+	return bundleFromFiles(func(name string) ([]byte, error) {
+		data, ok := files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return data, nil
+	})
+}
+
+// checkBundlePath rejects names that would escape the bundle root
+func checkBundlePath(name string) error {
+	clean := path.Clean(name)
+	if clean != name || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("invalid bundle path %q", name)
+	}
+	return nil
+}
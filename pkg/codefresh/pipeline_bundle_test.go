@@ -0,0 +1,160 @@
+package codefresh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const livePipeline = `{
+	"metadata": {
+		"id": "5f1d", "accountId": "acc", "name": "onprem/build", "project": "onprem", "revision": 7,
+		"projectId": "p-onprem", "isPinned": true,
+		"originalYamlString": "steps:\n  clone:\n    type: git-clone\n    git: onprem-github # source\n  push:\n    type: push\n    registry: \"onprem-registry\"\n    image: git\n  notes:\n    image: alpine\n    commands:\n      - echo git: onprem-github\n"
+	},
+	"spec": {
+		"contexts": ["onprem-secrets"],
+		"variables": [{"key": "TOKEN", "value": "*****", "encrypted": true}, {"key": "ENV", "value": "prod"}],
+		"triggers": [{"id": "src-1", "name": "push", "context": "onprem-github", "variables": [{"key": "KEY", "value": "*****", "encrypted": true}]}],
+		"cronTriggers": [{"name": "nightly", "expression": "0 2 * * *", "gitTriggerId": "src-1"}],
+		"steps": {
+			"clone": {"type": "git-clone", "git": "onprem-github"},
+			"push": {"type": "push", "registry": "onprem-registry"},
+			"build": {"type": "build", "registry_context": "onprem-registry"}
+		},
+		"runtimeEnvironment": {"name": "onprem/runner"}
+	}
+}`
+
+func TestPipelineExportImport(t *testing.T) {
+	created := &Pipeline{}
+	updated := &Pipeline{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.EscapedPath() == "/api/pipelines/onprem%2Fbuild":
+			w.Write([]byte(livePipeline))
+		case r.Method == "GET":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST":
+			json.NewDecoder(r.Body).Decode(created)
+			saved := copyPipeline(created)
+			saved.Spec.Triggers[0].ID = "dst-1"
+			json.NewEncoder(w).Encode(saved)
+		case r.Method == "PUT":
+			json.NewDecoder(r.Body).Decode(updated)
+			json.NewEncoder(w).Encode(updated)
+		}
+	}))
+	defer server.Close()
+	cf := New(&ClientOptions{Host: server.URL})
+
+	bundle, err := cf.Pipelines().ExportWithContext(context.Background(), &ExportOptions{Pipelines: []string{"onprem/build"}})
+	assert.NoError(t, err)
+	exported := bundle.Pipelines[0]
+	assert.Equal(t, "", exported.Metadata.ID)
+	assert.Equal(t, "", exported.Metadata.AccountID)
+	assert.Equal(t, 0, exported.Metadata.Revision)
+	assert.NotContains(t, exported.Metadata.Extra, "projectId")
+	assert.Contains(t, exported.Metadata.Extra, "isPinned")
+	assert.Equal(t, "<secret:onprem/build/TOKEN>", exported.Spec.Variables[0].Value)
+	assert.Equal(t, "prod", exported.Spec.Variables[1].Value)
+	assert.Equal(t, "<secret:onprem/build/triggers/push/KEY>", exported.Spec.Triggers[0].Variables[0].Value)
+
+	opt := &ImportOptions{
+		ProjectMap:            map[string]string{"onprem": "saas"},
+		ContextMap:            map[string]string{"onprem-secrets": "saas-secrets", "onprem-github": "saas-github", "onprem-registry": "saas-registry"},
+		RuntimeEnvironmentMap: map[string]string{"onprem/runner": "saas/runner"},
+		Secrets:               map[string]string{"onprem/build/TOKEN": "t0ken"},
+	}
+	_, err = cf.Pipelines().ImportWithContext(context.Background(), bundle, opt)
+	assert.EqualError(t, err, "failed to import bundle: missing secrets onprem/build/triggers/push/KEY")
+
+	opt.Secrets["onprem/build/triggers/push/KEY"] = "k3y"
+	res, err := cf.Pipelines().ImportWithContext(context.Background(), bundle, opt)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saas/build"}, res.Created)
+	assert.Equal(t, "saas", created.Metadata.Project)
+	assert.Equal(t, []string{"saas-secrets"}, created.Spec.Contexts)
+	assert.Equal(t, "t0ken", created.Spec.Variables[0].Value)
+	assert.Equal(t, "saas-github", created.Spec.Triggers[0].Context)
+	assert.Equal(t, "k3y", created.Spec.Triggers[0].Variables[0].Value)
+	assert.Equal(t, "saas/runner", created.Spec.RuntimeEnvironment.Name)
+	assert.Equal(t, "saas-github", created.Spec.Steps[0].Git)
+	assert.Equal(t, "saas-registry", created.Spec.Steps[1].Registry)
+	assert.Equal(t, `"saas-registry"`, string(created.Spec.Steps[2].Extra["registry_context"]))
+	assert.Equal(t, "steps:\n  clone:\n    type: git-clone\n    git: saas-github # source\n  push:\n    type: push\n    registry: \"saas-registry\"\n    image: git\n  notes:\n    image: alpine\n    commands:\n      - echo git: onprem-github\n",
+		created.Metadata.OriginalYamlString)
+
+	// source trigger IDs are dropped, the cron trigger is relinked to the ID assigned by the server
+	assert.Equal(t, "", created.Spec.Triggers[0].ID)
+	assert.Equal(t, "", created.Spec.CronTriggers[0].GitTriggerID)
+	assert.Equal(t, "dst-1", updated.Spec.CronTriggers[0].GitTriggerID)
+
+	// the bundle itself keeps the placeholders
+	assert.Equal(t, "<secret:onprem/build/TOKEN>", bundle.Pipelines[0].Spec.Variables[0].Value)
+}
+
+func TestPipelineImportExisting(t *testing.T) {
+	writes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.EscapedPath() == "/api/pipelines/proj%2Fb":
+			w.Write([]byte(`{"metadata": {"id": "5f1e", "name": "proj/b", "revision": 2}}`))
+		case r.Method == "GET":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			writes++
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+	cf := New(&ClientOptions{Host: server.URL})
+
+	bundle := &Bundle{Version: BundleVersion}
+	for _, name := range []string{"proj/a", "proj/b"} {
+		pl := &Pipeline{}
+		pl.Metadata.Name = name
+		bundle.Pipelines = append(bundle.Pipelines, pl)
+	}
+	_, err := cf.Pipelines().ImportWithContext(context.Background(), bundle, nil)
+	assert.EqualError(t, err, "failed to import bundle: pipelines already exist proj/b")
+	assert.Equal(t, 0, writes)
+
+	res, err := cf.Pipelines().ImportWithContext(context.Background(), bundle, &ImportOptions{Overwrite: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"proj/a"}, res.Created)
+	assert.Equal(t, []string{"proj/b"}, res.Updated)
+	assert.Equal(t, 2, writes)
+}
+
+func TestBundleDirAndTar(t *testing.T) {
+	pl := &Pipeline{}
+	assert.NoError(t, json.Unmarshal([]byte(livePipeline), pl))
+	bundle := &Bundle{Version: BundleVersion, Pipelines: []*Pipeline{exportPipeline(pl)}}
+
+	dir, err := ioutil.TempDir("", "bundle")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, bundle.WriteDir(dir))
+	_, err = os.Stat(dir + "/pipelines/onprem/build.yaml")
+	assert.NoError(t, err)
+	fromDir, err := ReadBundleDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle.Pipelines, fromDir.Pipelines)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, bundle.WriteTar(buf))
+	fromTar, err := ReadBundleTar(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle.Pipelines, fromTar.Pipelines)
+
+	bundle.Pipelines[0].Metadata.Name = "../escape"
+	assert.Error(t, bundle.WriteDir(dir))
+}
@@ -0,0 +1,548 @@
+package codefresh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Step types supported by the Codefresh pipeline engine
+const (
+	StepTypeFreestyle   = "freestyle"
+	StepTypeBuild       = "build"
+	StepTypePush        = "push"
+	StepTypeDeploy      = "deploy"
+	StepTypeComposition = "composition"
+	StepTypeParallel    = "parallel"
+	StepTypeApproval    = "pending-approval"
+	StepTypeGitClone    = "git-clone"
+)
+
+// Pipeline modes
+const (
+	PipelineModeSequential = "sequential"
+	PipelineModeParallel   = "parallel"
+)
+
+// The types below model the pipeline spec as returned by the API and as written in codefresh.yml,
+// every type keeps the keys it does not know in Extra so nothing is lost on a round trip
+
+type (
+	PipelineSpec struct {
+		Triggers     []Trigger     `json:"triggers,omitempty"`
+		CronTriggers []CronTrigger `json:"cronTriggers,omitempty"`
+		Contexts     []string      `json:"contexts,omitempty"`
+		Variables    []Variable    `json:"variables,omitempty"`
+		Steps        Steps         `json:"steps,omitempty"`
+		Stages       []string      `json:"stages,omitempty"`
+		// Mode - sequential (default) or parallel
+		Mode     string `json:"mode,omitempty"`
+		FailFast *bool  `json:"fail_fast,omitempty"`
+		Hooks    *Hooks `json:"hooks,omitempty"`
+		// RuntimeEnvironment - overrides the account default runtime environment and its resources
+		RuntimeEnvironment *RuntimeEnvironmentOverride `json:"runtimeEnvironment,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	// Trigger is a git trigger
+	Trigger struct {
+		ID          string   `json:"id,omitempty"`
+		Name        string   `json:"name,omitempty"`
+		Description string   `json:"description,omitempty"`
+		Type        string   `json:"type,omitempty"`
+		Repo        string   `json:"repo,omitempty"`
+		Events      []string `json:"events,omitempty"`
+		Provider    string   `json:"provider,omitempty"`
+		Context     string   `json:"context,omitempty"`
+		// Disabled - a pointer so that enabling sends false, ApplyWithContext keeps the live state when nil
+		Disabled *bool `json:"disabled,omitempty"`
+		// BranchRegex - only pushes to matching branches trigger a build
+		BranchRegex      string `json:"branchRegex,omitempty"`
+		BranchRegexInput string `json:"branchRegexInput,omitempty"`
+		// PullRequestTargetBranchRegex - only pull requests targeting matching branches trigger a build
+		PullRequestTargetBranchRegex string `json:"pullRequestTargetBranchRegex,omitempty"`
+		PullRequestAllowForkEvents   bool   `json:"pullRequestAllowForkEvents,omitempty"`
+		CommentRegex                 string `json:"commentRegex,omitempty"`
+		// ModifiedFilesGlob - only changes touching matching files trigger a build
+		ModifiedFilesGlob  string                      `json:"modifiedFilesGlob,omitempty"`
+		Contexts           []string                    `json:"contexts,omitempty"`
+		Variables          []Variable                  `json:"variables,omitempty"`
+		RuntimeEnvironment *RuntimeEnvironmentOverride `json:"runtimeEnvironment,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	CronTrigger struct {
+		Name    string `json:"name,omitempty"`
+		Type    string `json:"type,omitempty"`
+		Event   string `json:"event,omitempty"`
+		Message string `json:"message,omitempty"`
+		// Expression - standard cron expression, e.g. "0 2 * * *"
+		Expression   string `json:"expression,omitempty"`
+		GitTriggerID string `json:"gitTriggerId,omitempty"`
+		Branch       string `json:"branch,omitempty"`
+		// Disabled - a pointer so that enabling sends false, ApplyWithContext keeps the live state when nil
+		Disabled  *bool      `json:"disabled,omitempty"`
+		Variables []Variable `json:"variables,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	Variable struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+		// Encrypted - the value is stored encrypted and returned masked by the API
+		Encrypted bool `json:"encrypted,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	RuntimeEnvironmentOverride struct {
+		Name        string `json:"name,omitempty"`
+		CPU         string `json:"cpu,omitempty"`
+		Memory      string `json:"memory,omitempty"`
+		DindStorage string `json:"dindStorage,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	// Hooks run around a pipeline or a single step
+	Hooks struct {
+		OnElected *Hook `json:"on_elected,omitempty"`
+		OnSuccess *Hook `json:"on_success,omitempty"`
+		OnFail    *Hook `json:"on_fail,omitempty"`
+		OnFinish  *Hook `json:"on_finish,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	Hook struct {
+		Exec  *HookExec `json:"exec,omitempty"`
+		Steps Steps     `json:"steps,omitempty"`
+		Mode  string    `json:"mode,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	HookExec struct {
+		Image    string   `json:"image,omitempty"`
+		Commands []string `json:"commands,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	// Steps is an ordered set of named steps, the order is the execution order in sequential mode
+	Steps []*Step
+
+	// Step is a union of all the step types, Type selects which fields apply
+	Step struct {
+		// Name - the key of the step, not encoded in the step body
+		Name string `json:"-"`
+
+		Type        string `json:"type,omitempty"`
+		Title       string `json:"title,omitempty"`
+		Description string `json:"description,omitempty"`
+		Stage       string `json:"stage,omitempty"`
+
+		// freestyle
+		Image            string   `json:"image,omitempty"`
+		WorkingDirectory string   `json:"working_directory,omitempty"`
+		Commands         []string `json:"commands,omitempty"`
+		Shell            string   `json:"shell,omitempty"`
+		Environment      []string `json:"environment,omitempty"`
+
+		// build / push
+		ImageName      string   `json:"image_name,omitempty"`
+		Dockerfile     string   `json:"dockerfile,omitempty"`
+		Tag            string   `json:"tag,omitempty"`
+		Tags           []string `json:"tags,omitempty"`
+		BuildArguments []string `json:"build_arguments,omitempty"`
+		Target         string   `json:"target,omitempty"`
+		Candidate      string   `json:"candidate,omitempty"`
+		Registry       string   `json:"registry,omitempty"`
+
+		// deploy
+		Kind        string `json:"kind,omitempty"`
+		Cluster     string `json:"cluster,omitempty"`
+		Namespace   string `json:"namespace,omitempty"`
+		ServiceName string `json:"service,omitempty"`
+
+		// composition
+		Composition           json.RawMessage `json:"composition,omitempty"`
+		CompositionCandidates json.RawMessage `json:"composition_candidates,omitempty"`
+
+		// git-clone
+		Repo     string `json:"repo,omitempty"`
+		Revision string `json:"revision,omitempty"`
+		Git      string `json:"git,omitempty"`
+
+		// parallel
+		Steps Steps `json:"steps,omitempty"`
+
+		// Timeout - a duration string (e.g. "10m") for most steps, an object for pending-approval
+		Timeout *StepTimeout `json:"timeout,omitempty"`
+
+		// typed steps from the marketplace
+		Arguments json.RawMessage `json:"arguments,omitempty"`
+
+		When     *When  `json:"when,omitempty"`
+		FailFast *bool  `json:"fail_fast,omitempty"`
+		Retry    *Retry `json:"retry,omitempty"`
+		Hooks    *Hooks `json:"hooks,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	StepTimeout struct {
+		// Value - set when the timeout is a plain duration string, or a number as written
+		Value string `json:"-"`
+		// Duration - hours to wait for an approval
+		Duration   float64 `json:"duration,omitempty"`
+		FinalState string  `json:"finalState,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+
+		number bool
+	}
+
+	Retry struct {
+		MaxAttempts       int `json:"maxAttempts,omitempty"`
+		Delay             int `json:"delay,omitempty"`
+		ExponentialFactor int `json:"exponentialFactor,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	// When holds the conditions that decide whether a step runs
+	When struct {
+		Branch    *WhenBranch    `json:"branch,omitempty"`
+		Condition *WhenCondition `json:"condition,omitempty"`
+		Steps     *WhenSteps     `json:"steps,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	WhenBranch struct {
+		Only   []string `json:"only,omitempty"`
+		Ignore []string `json:"ignore,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	// WhenCondition maps a condition name to an expression, e.g. "'${{CF_BRANCH}}' == 'master'"
+	WhenCondition struct {
+		All map[string]string `json:"all,omitempty"`
+		Any map[string]string `json:"any,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+
+	// WhenSteps lists the steps a step depends on, a plain list in the YAML is treated as All
+	WhenSteps struct {
+		All []WhenStep `json:"all,omitempty"`
+		Any []WhenStep `json:"any,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+
+		list bool
+	}
+
+	WhenStep struct {
+		Name string `json:"name"`
+		// On - step states that satisfy the dependency (success, failure, skipped, finished, approved, denied)
+		On []string `json:"on,omitempty"`
+
+		Extra map[string]json.RawMessage `json:"-"`
+	}
+)
+
+// Get returns the step with the given name, or nil
+func (s Steps) Get(name string) *Step {
+	for _, step := range s {
+		if step.Name == name {
+			return step
+		}
+	}
+	return nil
+}
+
+func (s Steps) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, step := range s {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(step.Name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		data, err := json.Marshal(step)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (s *Steps) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		*s = nil
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("steps must be an object")
+	}
+	steps := Steps{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		step := &Step{}
+		if err := dec.Decode(step); err != nil {
+			return fmt.Errorf("failed to decode step %v: %w", token, err)
+		}
+		step.Name = token.(string)
+		steps = append(steps, step)
+	}
+	*s = steps
+	return nil
+}
+
+func (s Steps) MarshalYAML() (interface{}, error) {
+	return marshalYAMLViaJSON(s)
+}
+
+func (s *Steps) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return unmarshalYAMLViaJSON(unmarshal, s)
+}
+
+func (w WhenSteps) MarshalJSON() ([]byte, error) {
+	type alias WhenSteps
+	if w.list && len(w.Any) == 0 && len(w.Extra) == 0 {
+		return json.Marshal(w.All)
+	}
+	return marshalWithExtra(alias(w), w.Extra)
+}
+
+func (w *WhenSteps) UnmarshalJSON(data []byte) (err error) {
+	type alias WhenSteps
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		w.list = true
+		return json.Unmarshal(data, &w.All)
+	}
+	w.Extra, err = unmarshalWithExtra(data, (*alias)(w))
+	return err
+}
+
+func (w WhenStep) MarshalJSON() ([]byte, error) {
+	type alias WhenStep
+	return marshalWithExtra(alias(w), w.Extra)
+}
+
+func (w *WhenStep) UnmarshalJSON(data []byte) (err error) {
+	type alias WhenStep
+	w.Extra, err = unmarshalWithExtra(data, (*alias)(w))
+	return err
+}
+
+func (w WhenBranch) MarshalJSON() ([]byte, error) {
+	type alias WhenBranch
+	return marshalWithExtra(alias(w), w.Extra)
+}
+
+func (w *WhenBranch) UnmarshalJSON(data []byte) (err error) {
+	type alias WhenBranch
+	w.Extra, err = unmarshalWithExtra(data, (*alias)(w))
+	return err
+}
+
+func (w WhenCondition) MarshalJSON() ([]byte, error) {
+	type alias WhenCondition
+	return marshalWithExtra(alias(w), w.Extra)
+}
+
+func (w *WhenCondition) UnmarshalJSON(data []byte) (err error) {
+	type alias WhenCondition
+	w.Extra, err = unmarshalWithExtra(data, (*alias)(w))
+	return err
+}
+
+func (s PipelineSpec) MarshalJSON() ([]byte, error) {
+	type alias PipelineSpec
+	return marshalWithExtra(alias(s), s.Extra)
+}
+
+func (s *PipelineSpec) UnmarshalJSON(data []byte) (err error) {
+	type alias PipelineSpec
+	s.Extra, err = unmarshalWithExtra(data, (*alias)(s))
+	return err
+}
+
+func (s PipelineSpec) MarshalYAML() (interface{}, error) {
+	return marshalYAMLViaJSON(s)
+}
+
+func (s *PipelineSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return unmarshalYAMLViaJSON(unmarshal, s)
+}
+
+func (t Trigger) MarshalJSON() ([]byte, error) {
+	type alias Trigger
+	return marshalWithExtra(alias(t), t.Extra)
+}
+
+func (t *Trigger) UnmarshalJSON(data []byte) (err error) {
+	type alias Trigger
+	t.Extra, err = unmarshalWithExtra(data, (*alias)(t))
+	return err
+}
+
+func (t CronTrigger) MarshalJSON() ([]byte, error) {
+	type alias CronTrigger
+	return marshalWithExtra(alias(t), t.Extra)
+}
+
+func (t *CronTrigger) UnmarshalJSON(data []byte) (err error) {
+	type alias CronTrigger
+	t.Extra, err = unmarshalWithExtra(data, (*alias)(t))
+	return err
+}
+
+func (v Variable) MarshalJSON() ([]byte, error) {
+	type alias Variable
+	return marshalWithExtra(alias(v), v.Extra)
+}
+
+func (v *Variable) UnmarshalJSON(data []byte) (err error) {
+	type alias Variable
+	v.Extra, err = unmarshalWithExtra(data, (*alias)(v))
+	return err
+}
+
+func (r RuntimeEnvironmentOverride) MarshalJSON() ([]byte, error) {
+	type alias RuntimeEnvironmentOverride
+	return marshalWithExtra(alias(r), r.Extra)
+}
+
+func (r *RuntimeEnvironmentOverride) UnmarshalJSON(data []byte) (err error) {
+	type alias RuntimeEnvironmentOverride
+	r.Extra, err = unmarshalWithExtra(data, (*alias)(r))
+	return err
+}
+
+func (h Hooks) MarshalJSON() ([]byte, error) {
+	type alias Hooks
+	return marshalWithExtra(alias(h), h.Extra)
+}
+
+func (h *Hooks) UnmarshalJSON(data []byte) (err error) {
+	type alias Hooks
+	h.Extra, err = unmarshalWithExtra(data, (*alias)(h))
+	return err
+}
+
+func (h Hook) MarshalJSON() ([]byte, error) {
+	type alias Hook
+	return marshalWithExtra(alias(h), h.Extra)
+}
+
+func (h *Hook) UnmarshalJSON(data []byte) (err error) {
+	type alias Hook
+	h.Extra, err = unmarshalWithExtra(data, (*alias)(h))
+	return err
+}
+
+func (h HookExec) MarshalJSON() ([]byte, error) {
+	type alias HookExec
+	return marshalWithExtra(alias(h), h.Extra)
+}
+
+func (h *HookExec) UnmarshalJSON(data []byte) (err error) {
+	type alias HookExec
+	h.Extra, err = unmarshalWithExtra(data, (*alias)(h))
+	return err
+}
+
+func (s Step) MarshalJSON() ([]byte, error) {
+	type alias Step
+	return marshalWithExtra(alias(s), s.Extra)
+}
+
+func (s *Step) UnmarshalJSON(data []byte) (err error) {
+	type alias Step
+	s.Extra, err = unmarshalWithExtra(data, (*alias)(s))
+	return err
+}
+
+func (s Step) MarshalYAML() (interface{}, error) {
+	return marshalYAMLViaJSON(s)
+}
+
+func (s *Step) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return unmarshalYAMLViaJSON(unmarshal, s)
+}
+
+func (t StepTimeout) MarshalJSON() ([]byte, error) {
+	type alias StepTimeout
+	if t.number {
+		return []byte(t.Value), nil
+	}
+	if t.Value != "" {
+		return json.Marshal(t.Value)
+	}
+	return marshalWithExtra(alias(t), t.Extra)
+}
+
+func (t *StepTimeout) UnmarshalJSON(data []byte) (err error) {
+	type alias StepTimeout
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		return json.Unmarshal(data, &t.Value)
+	}
+	// a bare number, kept as written so it is encoded back the same way
+	var number json.Number
+	if err := json.Unmarshal(trimmed, &number); err == nil && number != "" {
+		t.Value, t.number = number.String(), true
+		return nil
+	}
+	t.Extra, err = unmarshalWithExtra(data, (*alias)(t))
+	return err
+}
+
+func (r Retry) MarshalJSON() ([]byte, error) {
+	type alias Retry
+	return marshalWithExtra(alias(r), r.Extra)
+}
+
+func (r *Retry) UnmarshalJSON(data []byte) (err error) {
+	type alias Retry
+	r.Extra, err = unmarshalWithExtra(data, (*alias)(r))
+	return err
+}
+
+func (w When) MarshalJSON() ([]byte, error) {
+	type alias When
+	return marshalWithExtra(alias(w), w.Extra)
+}
+
+func (w *When) UnmarshalJSON(data []byte) (err error) {
+	type alias When
+	w.Extra, err = unmarshalWithExtra(data, (*alias)(w))
+	return err
+}
+
+func (p Pipeline) MarshalYAML() (interface{}, error) {
+	return marshalYAMLViaJSON(p)
+}
+
+func (p *Pipeline) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return unmarshalYAMLViaJSON(unmarshal, p)
+}
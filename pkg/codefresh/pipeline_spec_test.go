@@ -0,0 +1,81 @@
+package codefresh
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const specJSON = `{
+	"triggers": [{"name": "push", "type": "git", "repo": "acme/app", "events": ["push.heads"], "branchRegex": "/^master$/", "verified": true}],
+	"cronTriggers": [{"name": "nightly", "type": "cron", "expression": "0 2 * * *"}],
+	"variables": [{"key": "TOKEN", "value": "*****", "encrypted": true}],
+	"stages": ["build", "test"],
+	"steps": {
+		"clone": {"type": "git-clone", "repo": "acme/app", "revision": "${{CF_BRANCH}}", "stage": "build"},
+		"build": {"type": "build", "image_name": "acme/app", "tag": "latest", "timeout": "10m"},
+		"tests": {
+			"type": "parallel",
+			"stage": "test",
+			"steps": {
+				"unit": {"image": "golang", "commands": ["go test ./..."], "retry": {"maxAttempts": 2}},
+				"lint": {"image": "golangci", "commands": ["golangci-lint run"], "when": {"steps": [{"name": "build", "on": ["success"]}]}}
+			}
+		},
+		"approve": {"type": "pending-approval", "timeout": {"duration": 2, "finalState": "denied"}, "customField": {"a": 1}},
+		"deploy": {
+			"image": "kubectl",
+			"timeout": 30,
+			"when": {
+				"branch": {"only": ["main"], "caseSensitive": false},
+				"condition": {"all": {"approved": "'${{APPROVED}}' == 'true'"}, "mode": "strict"},
+				"steps": {"any": [{"name": "approve", "on": ["approved"], "optional": true}], "policy": "latest"}
+			}
+		}
+	},
+	"fail_fast": false,
+	"specTemplate": {"location": "git"}
+}`
+
+func TestPipelineSpecJSONRoundTrip(t *testing.T) {
+	spec := &PipelineSpec{}
+	assert.NoError(t, json.Unmarshal([]byte(specJSON), spec))
+
+	assert.Equal(t, []string{"clone", "build", "tests", "approve"}, []string{spec.Steps[0].Name, spec.Steps[1].Name, spec.Steps[2].Name, spec.Steps[3].Name})
+	assert.Equal(t, "golang", spec.Steps.Get("tests").Steps.Get("unit").Image)
+	assert.Equal(t, "10m", spec.Steps.Get("build").Timeout.Value)
+	assert.Equal(t, 2.0, spec.Steps.Get("approve").Timeout.Duration)
+	assert.Equal(t, "build", spec.Steps.Get("tests").Steps.Get("lint").When.Steps.All[0].Name)
+	assert.True(t, spec.Variables[0].Encrypted)
+	assert.Contains(t, spec.Extra, "specTemplate")
+	assert.Contains(t, spec.Triggers[0].Extra, "verified")
+
+	deploy := spec.Steps.Get("deploy")
+	assert.Equal(t, "30", deploy.Timeout.Value)
+	assert.Contains(t, deploy.When.Branch.Extra, "caseSensitive")
+	assert.Contains(t, deploy.When.Condition.Extra, "mode")
+	assert.Contains(t, deploy.When.Steps.Extra, "policy")
+	assert.Contains(t, deploy.When.Steps.Any[0].Extra, "optional")
+
+	out, err := json.Marshal(spec)
+	assert.NoError(t, err)
+	assert.JSONEq(t, specJSON, string(out))
+}
+
+func TestPipelineSpecYAMLRoundTrip(t *testing.T) {
+	spec := &PipelineSpec{}
+	assert.NoError(t, json.Unmarshal([]byte(specJSON), spec))
+
+	data, err := yaml.Marshal(spec)
+	assert.NoError(t, err)
+
+	fromYAML := &PipelineSpec{}
+	assert.NoError(t, yaml.Unmarshal(data, fromYAML))
+	assert.Equal(t, "approve", fromYAML.Steps[3].Name)
+
+	out, err := json.Marshal(fromYAML)
+	assert.NoError(t, err)
+	assert.JSONEq(t, specJSON, string(out))
+}
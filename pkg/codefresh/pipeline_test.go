@@ -0,0 +1,81 @@
+package codefresh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelinePatchRetriesOnConflict(t *testing.T) {
+	var puts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/pipelines/proj%2Fbuild", r.URL.EscapedPath())
+		switch r.Method {
+		case "GET":
+			// encrypted values are masked unless decrypted explicitly
+			value := "*****"
+			if r.URL.Query().Get("decryptVariables") == "true" {
+				value = "s3cr3t"
+			}
+			w.Write([]byte(`{"metadata":{"name":"proj/build","revision":3,"shortLink":"cf.io/x"},
+				"spec":{"variables":[{"key":"TOKEN","value":"` + value + `","encrypted":true}]}}`))
+		case "PUT":
+			if atomic.AddInt32(&puts, 1) == 1 {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			body := &Pipeline{}
+			json.NewDecoder(r.Body).Decode(body)
+			assert.Equal(t, 3, body.Metadata.Revision)
+			assert.Equal(t, `"cf.io/x"`, string(body.Metadata.Extra["shortLink"]))
+			assert.Equal(t, "s3cr3t", body.Spec.Variables[0].Value)
+			body.Metadata.Revision++
+			json.NewEncoder(w).Encode(body)
+		}
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	p, err := cf.Pipelines().PatchWithContext(context.Background(), "proj/build", func(p *Pipeline) error {
+		p.Metadata.Labels.Tags = []string{"release"}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, p.Metadata.Revision)
+	assert.Equal(t, []string{"release"}, p.Metadata.Labels.Tags)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&puts))
+}
+
+func TestPipelineRunOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "abc123", body["sha"])
+		assert.Equal(t, map[string]interface{}{"noCache": true}, body["options"])
+		assert.Equal(t, []interface{}{"unit"}, body["only"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"key": "release", "value": "1.2"}}, body["annotations"])
+		w.Write([]byte(`"5f1d"`))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	res, err := cf.Pipelines().RunWithContext(context.Background(), "proj/build", &RunOptions{
+		SHA:         "abc123",
+		NoCache:     true,
+		Only:        []string{"unit"},
+		Annotations: map[string]string{"release": "1.2"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "5f1d", res.ID)
+	assert.Equal(t, server.URL+"/build/5f1d", res.URL)
+
+	_, err = cf.Pipelines().RunWithContext(context.Background(), "proj/build", &RunOptions{Skip: []string{"a"}, Only: []string{"b"}})
+	assert.Error(t, err)
+}
@@ -1,12 +1,14 @@
 package codefresh
 
 import (
+	"context"
 	"fmt"
 )
 
 type (
 	IProgressAPI interface {
 		Get(string) (*Progress, error)
+		GetWithContext(ctx context.Context, id string) (*Progress, error)
 	}
 
 	progress struct {
@@ -29,9 +31,14 @@ func newProgressAPI(codefresh *codefresh) IProgressAPI {
 	return &progress{codefresh}
 }
 
+// Deprecated: use GetWithContext
 func (p *progress) Get(id string) (*Progress, error) {
+	return p.GetWithContext(context.Background(), id)
+}
+
+func (p *progress) GetWithContext(ctx context.Context, id string) (*Progress, error) {
 	result := &Progress{}
-	resp, err := p.codefresh.requestAPI(&requestOptions{
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   fmt.Sprintf("/api/progress/%s", id),
 		method: "GET",
 	})
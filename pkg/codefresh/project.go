@@ -1,8 +1,14 @@
 package codefresh
 
+import "context"
+
 type (
 	IProjectAPI interface {
 		List() ([]*Project, error)
+		ListWithContext(ctx context.Context) ([]*Project, error)
+		ListPageWithContext(ctx context.Context, opt *ProjectListOptions) (*ProjectList, error)
+		ListAllWithContext(ctx context.Context, opt *ProjectListOptions) ([]*Project, error)
+		ForEachWithContext(ctx context.Context, opt *ProjectListOptions, fn func(*Project) error) error
 	}
 	project struct {
 		codefresh *codefresh
@@ -11,8 +17,26 @@ type (
 		ProjectName    string `json:"projectName"`
 		PipelineNumber int    `json:"pipelineNumber"`
 	}
+	// ProjectListOptions - filters for listing projects, Limit is used as the page size when iterating
+	ProjectListOptions struct {
+		Limit  int `url:"limit,omitempty"`
+		Offset int `url:"offset,omitempty"`
+		// Name - regular expression matched against the project name
+		Name string   `url:"name,omitempty"`
+		Tags []string `url:"tags,omitempty"`
+		// Sort - field to sort by, prefixed with "-" for descending order
+		Sort string `url:"sort,omitempty"`
+	}
+
+	// ProjectList - a single page of projects
+	ProjectList struct {
+		Projects []*Project
+		// Total - number of projects matching the filters
+		Total int
+	}
+
 	getProjectResponse struct {
-		Total    int        `json:"limit"`
+		Total    int        `json:"total"`
 		Projects []*Project `json:"projects"`
 	}
 )
@@ -21,10 +45,15 @@ func newProjectAPI(codefresh *codefresh) IProjectAPI {
 	return &project{codefresh}
 }
 
+// Deprecated: use ListWithContext
 func (p *project) List() ([]*Project, error) {
+	return p.ListWithContext(context.Background())
+}
+
+func (p *project) ListWithContext(ctx context.Context) ([]*Project, error) {
 	r := &getProjectResponse{}
 
-	resp, err := p.codefresh.requestAPI(&requestOptions{
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/projects",
 		method: "GET",
 	})
@@ -37,3 +66,56 @@ func (p *project) List() ([]*Project, error) {
 	}
 	return r.Projects, nil
 }
+
+// ListPageWithContext - returns a single page of projects and the total amount matching the filters
+func (p *project) ListPageWithContext(ctx context.Context, opt *ProjectListOptions) (*ProjectList, error) {
+	if opt == nil {
+		opt = &ProjectListOptions{}
+	}
+	r := &getProjectResponse{}
+	resp, err := p.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   "/api/projects",
+		method: "GET",
+		qs:     opt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = p.codefresh.decodeResponseInto(resp, r)
+	if err != nil {
+		return nil, err
+	}
+	return &ProjectList{Projects: r.Projects, Total: r.Total}, nil
+}
+
+// ListAllWithContext - returns every project matching the filters, walking all pages
+func (p *project) ListAllWithContext(ctx context.Context, opt *ProjectListOptions) ([]*Project, error) {
+	projects := []*Project{}
+	err := p.ForEachWithContext(ctx, opt, func(pr *Project) error {
+		projects = append(projects, pr)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// ForEachWithContext - calls fn for every project matching the filters, fetching pages lazily
+func (p *project) ForEachWithContext(ctx context.Context, opt *ProjectListOptions, fn func(*Project) error) error {
+	filters := ProjectListOptions{}
+	if opt != nil {
+		filters = *opt
+	}
+	return paginateOffset(ctx, filters.Offset, filters.Limit, func(ctx context.Context, offset int, limit int) (*offsetPage, error) {
+		filters.Offset = offset
+		filters.Limit = limit
+		list, err := p.ListPageWithContext(ctx, &filters)
+		if err != nil {
+			return nil, err
+		}
+		return &offsetPage{count: len(list.Projects), total: list.Total, item: func(i int) error {
+			return fn(list.Projects[i])
+		}}, nil
+	})
+}
@@ -0,0 +1,123 @@
+package codefresh
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// RateLimiterStats - counters collected by the client side rate limiter
+	RateLimiterStats struct {
+		// Requests - number of requests that passed the limiter, retries included
+		Requests int64
+		// Throttled - number of requests that had to wait for a token or a free slot
+		Throttled int64
+		// TotalWait - accumulated time spent waiting in the limiter
+		TotalWait time.Duration
+		// InFlight - number of requests currently being sent
+		InFlight int64
+	}
+
+	// limiter is shared by all the APIs returned from the same client
+	limiter struct {
+		mu     sync.Mutex
+		rate   float64
+		burst  float64
+		tokens float64
+		last   time.Time
+		slots  chan struct{}
+
+		requests  int64
+		throttled int64
+		waitNanos int64
+		inFlight  int64
+	}
+)
+
+func newLimiter(opt *RateLimitOptions) *limiter {
+	l := &limiter{}
+	if opt == nil {
+		return l
+	}
+	if opt.RequestsPerSecond > 0 {
+		l.rate = opt.RequestsPerSecond
+		l.burst = float64(opt.Burst)
+		if l.burst < 1 {
+			l.burst = 1
+		}
+		l.tokens = l.burst
+		l.last = time.Now()
+	}
+	if opt.MaxInFlight > 0 {
+		l.slots = make(chan struct{}, opt.MaxInFlight)
+	}
+	return l
+}
+
+// acquire blocks until the request is allowed to be sent, the returned func must be called once it is done
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+	if err := l.waitToken(ctx); err != nil {
+		return nil, err
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if waited := time.Since(start); waited > time.Millisecond {
+		atomic.AddInt64(&l.throttled, 1)
+		atomic.AddInt64(&l.waitNanos, int64(waited))
+	}
+	atomic.AddInt64(&l.requests, 1)
+	atomic.AddInt64(&l.inFlight, 1)
+	return l.release, nil
+}
+
+func (l *limiter) release() {
+	atomic.AddInt64(&l.inFlight, -1)
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *limiter) waitToken(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	deficit := -l.tokens
+	l.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+	if err := sleepWithContext(ctx, time.Duration(deficit/l.rate*float64(time.Second))); err != nil {
+		// give back the reserved token
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (l *limiter) stats() RateLimiterStats {
+	return RateLimiterStats{
+		Requests:  atomic.LoadInt64(&l.requests),
+		Throttled: atomic.LoadInt64(&l.throttled),
+		TotalWait: time.Duration(atomic.LoadInt64(&l.waitNanos)),
+		InFlight:  atomic.LoadInt64(&l.inFlight),
+	}
+}
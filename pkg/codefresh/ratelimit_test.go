@@ -0,0 +1,42 @@
+package codefresh
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterSharedAcrossAPIs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{
+		Host:      server.URL,
+		RateLimit: &RateLimitOptions{RequestsPerSecond: 50, Burst: 1, MaxInFlight: 2},
+	})
+
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cf.Workflows().Get("1")
+			cf.Progresses().Get("1")
+		}()
+	}
+	wg.Wait()
+
+	stats := cf.RateLimiterStats()
+	assert.Equal(t, int64(10), stats.Requests)
+	assert.Equal(t, int64(0), stats.InFlight)
+	assert.True(t, stats.Throttled > 0)
+	// 10 requests at 50/s with a burst of 1 take at least 9 * 20ms
+	assert.True(t, time.Since(start) >= 170*time.Millisecond)
+}
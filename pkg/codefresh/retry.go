@@ -0,0 +1,166 @@
+package codefresh
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+)
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type retryPolicy struct {
+	maxAttempts        int
+	initialBackoff     time.Duration
+	maxBackoff         time.Duration
+	retryNonIdempotent bool
+	statusCodes        map[int]bool
+}
+
+func newRetryPolicy(opt *RetryOptions) *retryPolicy {
+	p := &retryPolicy{
+		maxAttempts:    1,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		statusCodes:    map[int]bool{},
+	}
+	if opt == nil {
+		return p
+	}
+	if opt.MaxAttempts > 1 {
+		p.maxAttempts = opt.MaxAttempts
+	}
+	if opt.InitialBackoff > 0 {
+		p.initialBackoff = opt.InitialBackoff
+	}
+	if opt.MaxBackoff > 0 {
+		p.maxBackoff = opt.MaxBackoff
+	}
+	p.retryNonIdempotent = opt.RetryNonIdempotent
+	codes := opt.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryableStatusCodes
+	}
+	for _, code := range codes {
+		p.statusCodes[code] = true
+	}
+	return p
+}
+
+// attempts returns how many times the request may be sent
+func (p *retryPolicy) attempts(opt *requestOptions) int {
+	if opt.idempotent || p.retryNonIdempotent || isIdempotentMethod(opt.method) {
+		return p.maxAttempts
+	}
+	return 1
+}
+
+// shouldRetry reports whether the outcome of a single attempt is transient
+func (p *retryPolicy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isTransientError(err)
+	}
+	return p.statusCodes[resp.StatusCode]
+}
+
+// isTransientError reports whether a transport error may go away on its own: timeouts, temporary network
+// errors and connections closed by the server, never certificate, TLS, invalid URL or cancellation errors
+func isTransientError(err error) bool {
+	// a deadline of the caller is caught by shouldRetry, other deadlines are per attempt timeouts
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var tlsRecord tls.RecordHeaderError
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalidCert) || errors.As(err, &tlsRecord) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && (netErr.Timeout() || netErr.Temporary())
+}
+
+// backoff returns the delay before the next attempt, attempt starts at 1
+func (p *retryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+	d := p.initialBackoff << uint(attempt-1)
+	if d <= 0 || d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+	// equal jitter: half fixed, half random
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		d := time.Until(date)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// discardResponse drains and closes a response that is not returned to the caller
+func discardResponse(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
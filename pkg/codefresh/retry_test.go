@@ -0,0 +1,101 @@
+package codefresh
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryOnTransientStatus(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"1","status":"success"}`))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{
+		Host:  server.URL,
+		Retry: &RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	})
+	wf, err := cf.Workflows().Get("1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "success", wf.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRetrySkipsPostByDefault(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{
+		Host:  server.URL,
+		Retry: &RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	})
+	_, err := cf.Pipelines().Run("pipeline", nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	d, ok := parseRetryAfter("7")
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryTransportErrors(t *testing.T) {
+	p := newRetryPolicy(&RetryOptions{MaxAttempts: 3})
+	urlError := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://g.codefresh.io/api/workflow/1", Err: err}
+	}
+	cases := map[string]struct {
+		err   error
+		retry bool
+	}{
+		"timeout":            {urlError(timeoutError{}), true},
+		"connection closed":  {urlError(io.ErrUnexpectedEOF), true},
+		"unknown authority":  {urlError(x509.UnknownAuthorityError{}), false},
+		"hostname mismatch":  {urlError(x509.HostnameError{Host: "example.com"}), false},
+		"tls record":         {urlError(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), false},
+		"invalid url":        {urlError(errors.New("unsupported protocol scheme \"\"")), false},
+		"context canceled":   {urlError(context.Canceled), false},
+		"connection refused": {urlError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}), false},
+		"client timeout":     {urlError(fmt.Errorf("%w (Client.Timeout exceeded while awaiting headers)", context.DeadlineExceeded)), true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.retry, p.shouldRetry(context.Background(), nil, c.err))
+		})
+	}
+}
@@ -1,9 +1,9 @@
 package codefresh
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/url"
 	"time"
 )
@@ -16,12 +16,22 @@ type (
 	// IRuntimeEnvironmentAPI declers Codefresh runtime environment API
 	IRuntimeEnvironmentAPI interface {
 		Create(*CreateRuntimeOptions) (*RuntimeEnvironment, error)
+		CreateWithContext(ctx context.Context, opt *CreateRuntimeOptions) (*RuntimeEnvironment, error)
 		Validate(*ValidateRuntimeOptions) error
+		ValidateWithContext(ctx context.Context, opt *ValidateRuntimeOptions) error
 		SignCertificate(*SignCertificatesOptions) ([]byte, error)
+		SignCertificateWithContext(ctx context.Context, opt *SignCertificatesOptions) ([]byte, error)
 		Get(string) (*RuntimeEnvironment, error)
+		GetWithContext(ctx context.Context, name string) (*RuntimeEnvironment, error)
 		List() ([]*RuntimeEnvironment, error)
+		ListWithContext(ctx context.Context) ([]*RuntimeEnvironment, error)
+		ListPageWithContext(ctx context.Context, opt *RuntimeEnvironmentListOptions) ([]*RuntimeEnvironment, error)
+		ListAllWithContext(ctx context.Context, opt *RuntimeEnvironmentListOptions) ([]*RuntimeEnvironment, error)
+		ForEachWithContext(ctx context.Context, opt *RuntimeEnvironmentListOptions, fn func(*RuntimeEnvironment) error) error
 		Delete(string) (bool, error)
+		DeleteWithContext(ctx context.Context, name string) (bool, error)
 		Default(string) (bool, error)
+		DefaultWithContext(ctx context.Context, name string) (bool, error)
 	}
 
 	RuntimeEnvironment struct {
@@ -88,6 +98,16 @@ type (
 		Namespace string
 	}
 
+	// RuntimeEnvironmentListOptions - filters for listing runtime environments, Limit is used as the page size when iterating
+	RuntimeEnvironmentListOptions struct {
+		Limit  int `url:"limit,omitempty"`
+		Offset int `url:"offset,omitempty"`
+		// Name - regular expression matched against the runtime environment name
+		Name string `url:"name,omitempty"`
+		// Sort - field to sort by, prefixed with "-" for descending order
+		Sort string `url:"sort,omitempty"`
+	}
+
 	SignCertificatesOptions struct {
 		AltName string
 		CSR     string
@@ -107,7 +127,14 @@ func newRuntimeEnvironmentAPI(codefresh *codefresh) IRuntimeEnvironmentAPI {
 }
 
 // Create - create Runtime-Environment
+//
+// Deprecated: use CreateWithContext
 func (r *runtimeEnvironment) Create(opt *CreateRuntimeOptions) (*RuntimeEnvironment, error) {
+	return r.CreateWithContext(context.Background(), opt)
+}
+
+// CreateWithContext - create Runtime-Environment
+func (r *runtimeEnvironment) CreateWithContext(ctx context.Context, opt *CreateRuntimeOptions) (*RuntimeEnvironment, error) {
 	re := &RuntimeEnvironment{
 		Metadata: RuntimeMetadata{
 			Name: fmt.Sprintf("%s/%s", opt.Cluster, opt.Namespace),
@@ -125,33 +152,30 @@ func (r *runtimeEnvironment) Create(opt *CreateRuntimeOptions) (*RuntimeEnvironm
 	if opt.HasAgent {
 		body["agent"] = true
 	}
-	resp, err := r.codefresh.requestAPI(&requestOptions{
+	resp, err := r.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/custom_clusters/register",
 		method: "POST",
 		body:   body,
 	})
 
 	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode < 400 {
-		return re, nil
+		return nil, fmt.Errorf("Error during runtime environment creation, error: %w", err)
 	}
-	defer resp.Body.Close()
-	buffer, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return nil, fmt.Errorf("Error during runtime environment creation, error: %s", string(buffer))
+	resp.Body.Close()
+	return re, nil
 }
 
+// Deprecated: use ValidateWithContext
 func (r *runtimeEnvironment) Validate(opt *ValidateRuntimeOptions) error {
+	return r.ValidateWithContext(context.Background(), opt)
+}
+
+func (r *runtimeEnvironment) ValidateWithContext(ctx context.Context, opt *ValidateRuntimeOptions) error {
 	body := map[string]interface{}{
 		"clusterName": opt.Cluster,
 		"namespace":   opt.Namespace,
 	}
-	_, err := r.codefresh.requestAPI(&requestOptions{
+	_, err := r.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/custom_clusters/validate",
 		method: "POST",
 		body:   body,
@@ -159,12 +183,17 @@ func (r *runtimeEnvironment) Validate(opt *ValidateRuntimeOptions) error {
 	return err
 }
 
+// Deprecated: use SignCertificateWithContext
 func (r *runtimeEnvironment) SignCertificate(opt *SignCertificatesOptions) ([]byte, error) {
+	return r.SignCertificateWithContext(context.Background(), opt)
+}
+
+func (r *runtimeEnvironment) SignCertificateWithContext(ctx context.Context, opt *SignCertificatesOptions) ([]byte, error) {
 	body := map[string]interface{}{
 		"reqSubjectAltName": opt.AltName,
 		"csr":               opt.CSR,
 	}
-	resp, err := r.codefresh.requestAPI(&requestOptions{
+	resp, err := r.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/custom_clusters/signServerCerts",
 		method: "POST",
 		body:   body,
@@ -175,10 +204,15 @@ func (r *runtimeEnvironment) SignCertificate(opt *SignCertificatesOptions) ([]by
 	return r.codefresh.getBodyAsBytes(resp)
 }
 
+// Deprecated: use GetWithContext
 func (r *runtimeEnvironment) Get(name string) (*RuntimeEnvironment, error) {
+	return r.GetWithContext(context.Background(), name)
+}
+
+func (r *runtimeEnvironment) GetWithContext(ctx context.Context, name string) (*RuntimeEnvironment, error) {
 	re := &RuntimeEnvironment{}
 	path := fmt.Sprintf("/api/runtime-environments/%s", url.PathEscape(name))
-	resp, err := r.codefresh.requestAPI(&requestOptions{
+	resp, err := r.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   path,
 		method: "GET",
 		qs: map[string]string{
@@ -187,19 +221,29 @@ func (r *runtimeEnvironment) Get(name string) (*RuntimeEnvironment, error) {
 	})
 
 	if err != nil {
-		fmt.Println(err.Error())
 		return nil, err
 	}
-	r.codefresh.decodeResponseInto(resp, re)
+	err = r.codefresh.decodeResponseInto(resp, re)
+	if err != nil {
+		return nil, err
+	}
 	return re, nil
 }
 
+// Deprecated: use ListWithContext
 func (r *runtimeEnvironment) List() ([]*RuntimeEnvironment, error) {
+	return r.ListWithContext(context.Background())
+}
+
+func (r *runtimeEnvironment) ListWithContext(ctx context.Context) ([]*RuntimeEnvironment, error) {
 	emptySlice := make([]*RuntimeEnvironment, 0)
-	resp, err := r.codefresh.requestAPI(&requestOptions{
+	resp, err := r.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/runtime-environments",
 		method: "GET",
 	})
+	if err != nil {
+		return nil, err
+	}
 	tokensAsBytes, err := r.codefresh.getBodyAsBytes(resp)
 	if err != nil {
 		return nil, err
@@ -209,28 +253,93 @@ func (r *runtimeEnvironment) List() ([]*RuntimeEnvironment, error) {
 	return emptySlice, err
 }
 
-func (r *runtimeEnvironment) Delete(name string) (bool, error) {
-	resp, err := r.codefresh.requestAPI(&requestOptions{
-		path:   fmt.Sprintf("/api/runtime-environments/%s", url.PathEscape(name)),
-		method: "DELETE",
+// ListPageWithContext - returns a single page of runtime environments
+func (r *runtimeEnvironment) ListPageWithContext(ctx context.Context, opt *RuntimeEnvironmentListOptions) ([]*RuntimeEnvironment, error) {
+	if opt == nil {
+		opt = &RuntimeEnvironmentListOptions{}
+	}
+	result := make([]*RuntimeEnvironment, 0)
+	resp, err := r.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   "/api/runtime-environments",
+		method: "GET",
+		qs:     opt,
 	})
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	err = r.codefresh.decodeResponseInto(resp, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	if resp.StatusCode < 400 {
-		return true, nil
+// ListAllWithContext - returns every runtime environment matching the filters, walking all pages
+func (r *runtimeEnvironment) ListAllWithContext(ctx context.Context, opt *RuntimeEnvironmentListOptions) ([]*RuntimeEnvironment, error) {
+	res := []*RuntimeEnvironment{}
+	err := r.ForEachWithContext(ctx, opt, func(re *RuntimeEnvironment) error {
+		res = append(res, re)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ForEachWithContext - calls fn for every runtime environment matching the filters, fetching pages lazily
+func (r *runtimeEnvironment) ForEachWithContext(ctx context.Context, opt *RuntimeEnvironmentListOptions, fn func(*RuntimeEnvironment) error) error {
+	filters := RuntimeEnvironmentListOptions{}
+	if opt != nil {
+		filters = *opt
 	}
-	body, err := r.codefresh.getBodyAsString(resp)
+	firstName := ""
+	return paginateOffset(ctx, filters.Offset, filters.Limit, func(ctx context.Context, offset int, limit int) (*offsetPage, error) {
+		filters.Offset = offset
+		filters.Limit = limit
+		page, err := r.ListPageWithContext(ctx, &filters)
+		if err != nil {
+			return nil, err
+		}
+		// the endpoint does not report a total, a short page ends the iteration, and so does
+		// a page starting with the same runtime as the previous one in case the offset is ignored
+		if len(page) > 0 {
+			if page[0].Metadata.Name == firstName {
+				return &offsetPage{}, nil
+			}
+			firstName = page[0].Metadata.Name
+		}
+		return &offsetPage{count: len(page), item: func(i int) error {
+			return fn(page[i])
+		}}, nil
+	})
+}
+
+// Deprecated: use DeleteWithContext
+func (r *runtimeEnvironment) Delete(name string) (bool, error) {
+	return r.DeleteWithContext(context.Background(), name)
+}
+
+func (r *runtimeEnvironment) DeleteWithContext(ctx context.Context, name string) (bool, error) {
+	resp, err := r.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/runtime-environments/%s", url.PathEscape(name)),
+		method: "DELETE",
+	})
 	if err != nil {
 		return false, err
 	}
-	return false, fmt.Errorf(body)
+	resp.Body.Close()
+	return true, nil
 }
 
+// Deprecated: use DefaultWithContext
 func (r *runtimeEnvironment) Default(name string) (bool, error) {
+	return r.DefaultWithContext(context.Background(), name)
+}
+
+func (r *runtimeEnvironment) DefaultWithContext(ctx context.Context, name string) (bool, error) {
 	path := fmt.Sprintf("/api/runtime-environments/default/%s", url.PathEscape(name))
-	resp, err := r.codefresh.requestAPI(&requestOptions{
+	resp, err := r.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   path,
 		method: "PUT",
 	})
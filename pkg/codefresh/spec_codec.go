@@ -0,0 +1,208 @@
+package codefresh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The pipeline spec types are encoded through JSON only, the YAML form is produced by
+// converting to and from JSON while keeping the order of mapping keys
+
+var knownFieldsCache sync.Map
+
+// knownJSONFields returns the json names of the fields of the struct type t
+func knownJSONFields(t reflect.Type) map[string]bool {
+	if cached, ok := knownFieldsCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+	known := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		known[name] = true
+	}
+	knownFieldsCache.Store(t, known)
+	return known
+}
+
+// unmarshalWithExtra decodes data into target, a pointer to a struct without custom
+// unmarshaling, and returns the object keys that target does not declare
+func unmarshalWithExtra(data []byte, target interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, target); err != nil {
+		return nil, err
+	}
+	all := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	known := knownJSONFields(reflect.TypeOf(target).Elem())
+	for k := range all {
+		if known[k] {
+			delete(all, k)
+		}
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all, nil
+}
+
+// marshalWithExtra encodes v and appends the extra keys, sorted, to the resulting object
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := &bytes.Buffer{}
+	buf.Write(data[:len(data)-1])
+	empty := len(data) == 2
+	for _, k := range keys {
+		if !empty {
+			buf.WriteByte(',')
+		}
+		empty = false
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(extra[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// unmarshalYAMLViaJSON implements yaml.Unmarshaler on top of the JSON decoding of target
+func unmarshalYAMLViaJSON(unmarshal func(interface{}) error, target interface{}) error {
+	var value interface{}
+	var ordered yaml.MapSlice
+	if err := unmarshal(&ordered); err == nil {
+		value = ordered
+	} else if err := unmarshal(&value); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err := writeYAMLValueAsJSON(buf, value); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf.Bytes(), target)
+}
+
+// marshalYAMLViaJSON implements yaml.Marshaler on top of the JSON encoding of v
+func marshalYAMLViaJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return readJSONAsYAMLValue(dec)
+}
+
+func writeYAMLValueAsJSON(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		buf.WriteByte('{')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(fmt.Sprint(item.Key))
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeYAMLValueAsJSON(buf, item.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case map[interface{}]interface{}:
+		ordered := yaml.MapSlice{}
+		for k, inner := range v {
+			ordered = append(ordered, yaml.MapItem{Key: k, Value: inner})
+		}
+		sort.Slice(ordered, func(i, j int) bool {
+			return fmt.Sprint(ordered[i].Key) < fmt.Sprint(ordered[j].Key)
+		})
+		return writeYAMLValueAsJSON(buf, ordered)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYAMLValueAsJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to convert yaml value %v: %w", v, err)
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+func readJSONAsYAMLValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			ordered := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := readJSONAsYAMLValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				ordered = append(ordered, yaml.MapItem{Key: key, Value: value})
+			}
+			_, err = dec.Token()
+			return ordered, err
+		case '[':
+			list := []interface{}{}
+			for dec.More() {
+				value, err := readJSONAsYAMLValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			_, err = dec.Token()
+			return list, err
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+	return token, nil
+}
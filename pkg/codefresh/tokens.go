@@ -1,6 +1,7 @@
 package codefresh
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 )
@@ -8,7 +9,9 @@ import (
 type (
 	ITokenAPI interface {
 		Create(name string, subject string) (*Token, error)
+		CreateWithContext(ctx context.Context, name string, subject string) (*Token, error)
 		List() ([]*Token, error)
+		ListWithContext(ctx context.Context) ([]*Token, error)
 	}
 
 	Token struct {
@@ -46,8 +49,13 @@ func (s tokenSubjectType) String() string {
 	return [...]string{"runtime-environment"}[s]
 }
 
+// Deprecated: use CreateWithContext
 func (t *token) Create(name string, subject string) (*Token, error) {
-	resp, err := t.codefresh.requestAPI(&requestOptions{
+	return t.CreateWithContext(context.Background(), name, subject)
+}
+
+func (t *token) CreateWithContext(ctx context.Context, name string, subject string) (*Token, error) {
+	resp, err := t.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/auth/key",
 		method: "POST",
 		body: map[string]interface{}{
@@ -58,6 +66,9 @@ func (t *token) Create(name string, subject string) (*Token, error) {
 			"subjectType":      runtimeEnvironmentSubject.String(),
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
 	value, err := t.codefresh.getBodyAsString(resp)
 	if err != nil {
 		return nil, err
@@ -68,12 +79,20 @@ func (t *token) Create(name string, subject string) (*Token, error) {
 	}, err
 }
 
+// Deprecated: use ListWithContext
 func (t *token) List() ([]*Token, error) {
+	return t.ListWithContext(context.Background())
+}
+
+func (t *token) ListWithContext(ctx context.Context) ([]*Token, error) {
 	emptySlice := make([]*Token, 0)
-	resp, err := t.codefresh.requestAPI(&requestOptions{
+	resp, err := t.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   "/api/auth/keys",
 		method: "GET",
 	})
+	if err != nil {
+		return nil, err
+	}
 	tokensAsBytes, err := t.codefresh.getBodyAsBytes(resp)
 	if err != nil {
 		return nil, err
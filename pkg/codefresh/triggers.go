@@ -0,0 +1,404 @@
+package codefresh
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const registryEventPrefix = "registry:"
+
+var jsRegexpLiteral = regexp.MustCompile(`^/(.*)/([gimsuy]*)$`)
+
+type (
+	// ITriggerAPI manages the git, cron and registry triggers of a pipeline,
+	// pipelines are identified by their full name (project/name) or ID.
+	// Like the V2 APIs it only has context forms, so its methods carry no WithContext suffix,
+	// which is kept for the older APIs that still have methods without a context
+	ITriggerAPI interface {
+		List(ctx context.Context, pipeline string) (*PipelineTriggers, error)
+		AddGit(ctx context.Context, pipeline string, trigger *Trigger) (*Trigger, error)
+		UpdateGit(ctx context.Context, pipeline string, trigger *Trigger) (*Trigger, error)
+		AddCron(ctx context.Context, pipeline string, trigger *CronTrigger) (*CronTrigger, error)
+		UpdateCron(ctx context.Context, pipeline string, trigger *CronTrigger) (*CronTrigger, error)
+		Enable(ctx context.Context, pipeline string, name string) error
+		Disable(ctx context.Context, pipeline string, name string) error
+		Delete(ctx context.Context, pipeline string, name string) error
+		AddRegistry(ctx context.Context, pipeline string, event string) error
+		DeleteRegistry(ctx context.Context, pipeline string, event string) error
+		Fire(ctx context.Context, pipeline string, name string, event *GitEvent) (*RunResult, error)
+	}
+
+	triggers struct {
+		codefresh *codefresh
+	}
+
+	// PipelineTriggers - every trigger of a pipeline, by kind
+	PipelineTriggers struct {
+		Git      []Trigger
+		Cron     []CronTrigger
+		Registry []RegistryTrigger
+	}
+
+	// RegistryTrigger links a docker registry event to a pipeline
+	RegistryTrigger struct {
+		// Event - event URI, e.g. registry:dockerhub:codefresh:go-sdk:push:<account id>
+		Event    string `json:"event"`
+		Pipeline string `json:"pipeline"`
+	}
+
+	// GitEvent is a synthetic git event used to test-fire a git trigger
+	GitEvent struct {
+		// Type - one of the trigger events, e.g. push.heads or pullrequest.opened (default push.heads)
+		Type   string
+		Branch string
+		SHA    string
+		// Variables - added to the variables of the trigger
+		Variables map[string]string
+	}
+)
+
+func newTriggerAPI(codefresh *codefresh) ITriggerAPI {
+	return &triggers{codefresh}
+}
+
+// List - returns the triggers of the pipeline
+func (t *triggers) List(ctx context.Context, pipeline string) (*PipelineTriggers, error) {
+	p, err := t.codefresh.Pipelines().GetWithContext(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := t.listRegistry(ctx, p.Metadata.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &PipelineTriggers{
+		Git:      p.Spec.Triggers,
+		Cron:     p.Spec.CronTriggers,
+		Registry: registry,
+	}, nil
+}
+
+// AddGit - adds a git trigger, fails when the pipeline already has a trigger with the same name
+func (t *triggers) AddGit(ctx context.Context, pipeline string, trigger *Trigger) (*Trigger, error) {
+	if trigger.Name == "" {
+		return nil, fmt.Errorf("failed to add trigger: name is required")
+	}
+	p, err := t.codefresh.Pipelines().PatchWithContext(ctx, pipeline, func(p *Pipeline) error {
+		if findTrigger(p, trigger.Name) >= 0 || findCronTrigger(p, trigger.Name) >= 0 {
+			return fmt.Errorf("failed to add trigger: %s already has a trigger named %s", pipeline, trigger.Name)
+		}
+		p.Spec.Triggers = append(p.Spec.Triggers, *trigger)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storedTrigger(p, trigger.Name)
+}
+
+// UpdateGit - replaces the git trigger with the same name
+func (t *triggers) UpdateGit(ctx context.Context, pipeline string, trigger *Trigger) (*Trigger, error) {
+	p, err := t.codefresh.Pipelines().PatchWithContext(ctx, pipeline, func(p *Pipeline) error {
+		i := findTrigger(p, trigger.Name)
+		if i < 0 {
+			return fmt.Errorf("failed to update trigger: %s has no git trigger named %s", pipeline, trigger.Name)
+		}
+		updated := *trigger
+		if updated.ID == "" {
+			updated.ID = p.Spec.Triggers[i].ID
+		}
+		p.Spec.Triggers[i] = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storedTrigger(p, trigger.Name)
+}
+
+// AddCron - adds a cron trigger, fails when the pipeline already has a trigger with the same name
+func (t *triggers) AddCron(ctx context.Context, pipeline string, trigger *CronTrigger) (*CronTrigger, error) {
+	if trigger.Name == "" {
+		return nil, fmt.Errorf("failed to add trigger: name is required")
+	}
+	p, err := t.codefresh.Pipelines().PatchWithContext(ctx, pipeline, func(p *Pipeline) error {
+		if findTrigger(p, trigger.Name) >= 0 || findCronTrigger(p, trigger.Name) >= 0 {
+			return fmt.Errorf("failed to add trigger: %s already has a trigger named %s", pipeline, trigger.Name)
+		}
+		p.Spec.CronTriggers = append(p.Spec.CronTriggers, *trigger)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storedCronTrigger(p, trigger.Name)
+}
+
+// UpdateCron - replaces the cron trigger with the same name
+func (t *triggers) UpdateCron(ctx context.Context, pipeline string, trigger *CronTrigger) (*CronTrigger, error) {
+	p, err := t.codefresh.Pipelines().PatchWithContext(ctx, pipeline, func(p *Pipeline) error {
+		i := findCronTrigger(p, trigger.Name)
+		if i < 0 {
+			return fmt.Errorf("failed to update trigger: %s has no cron trigger named %s", pipeline, trigger.Name)
+		}
+		p.Spec.CronTriggers[i] = *trigger
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storedCronTrigger(p, trigger.Name)
+}
+
+// Enable - enables the git or cron trigger with the given name, or links the registry event
+// when name is one, registry triggers have no disabled state of their own
+func (t *triggers) Enable(ctx context.Context, pipeline string, name string) error {
+	if strings.HasPrefix(name, registryEventPrefix) {
+		return t.AddRegistry(ctx, pipeline, name)
+	}
+	return t.setDisabled(ctx, pipeline, name, false)
+}
+
+// Disable - disables the git or cron trigger with the given name, or unlinks the registry event
+// when name is one, Enable links it again
+func (t *triggers) Disable(ctx context.Context, pipeline string, name string) error {
+	if strings.HasPrefix(name, registryEventPrefix) {
+		return t.unlinkRegistry(ctx, pipeline, name, "disable")
+	}
+	return t.setDisabled(ctx, pipeline, name, true)
+}
+
+func (t *triggers) setDisabled(ctx context.Context, pipeline string, name string, disabled bool) error {
+	_, err := t.codefresh.Pipelines().PatchWithContext(ctx, pipeline, func(p *Pipeline) error {
+		if i := findTrigger(p, name); i >= 0 {
+			p.Spec.Triggers[i].Disabled = &disabled
+			return nil
+		}
+		if i := findCronTrigger(p, name); i >= 0 {
+			p.Spec.CronTriggers[i].Disabled = &disabled
+			return nil
+		}
+		return fmt.Errorf("failed to update trigger: %s has no trigger named %s", pipeline, name)
+	})
+	return err
+}
+
+// Delete - deletes the git or cron trigger with the given name, or unlinks the registry event when name is one
+func (t *triggers) Delete(ctx context.Context, pipeline string, name string) error {
+	if strings.HasPrefix(name, registryEventPrefix) {
+		return t.unlinkRegistry(ctx, pipeline, name, "delete")
+	}
+	_, err := t.codefresh.Pipelines().PatchWithContext(ctx, pipeline, func(p *Pipeline) error {
+		if i := findTrigger(p, name); i >= 0 {
+			p.Spec.Triggers = append(p.Spec.Triggers[:i], p.Spec.Triggers[i+1:]...)
+			return nil
+		}
+		if i := findCronTrigger(p, name); i >= 0 {
+			p.Spec.CronTriggers = append(p.Spec.CronTriggers[:i], p.Spec.CronTriggers[i+1:]...)
+			return nil
+		}
+		return fmt.Errorf("failed to delete trigger: %s has no trigger named %s", pipeline, name)
+	})
+	return err
+}
+
+// AddRegistry - links a registry event to the pipeline
+func (t *triggers) AddRegistry(ctx context.Context, pipeline string, event string) error {
+	return t.linkRegistry(ctx, "POST", pipeline, event)
+}
+
+// DeleteRegistry - unlinks a registry event from the pipeline
+func (t *triggers) DeleteRegistry(ctx context.Context, pipeline string, event string) error {
+	return t.linkRegistry(ctx, "DELETE", pipeline, event)
+}
+
+// unlinkRegistry unlinks a registry event, failing like the git and cron triggers when it is not linked
+func (t *triggers) unlinkRegistry(ctx context.Context, pipeline string, event string, operation string) error {
+	p, err := t.codefresh.Pipelines().GetWithContext(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	registry, err := t.listRegistry(ctx, p.Metadata.ID)
+	if err != nil {
+		return err
+	}
+	for _, trigger := range registry {
+		if trigger.Event == event {
+			return t.linkRegistry(ctx, "DELETE", pipeline, event)
+		}
+	}
+	return fmt.Errorf("failed to %s trigger: %s has no trigger named %s", operation, pipeline, event)
+}
+
+func (t *triggers) linkRegistry(ctx context.Context, method string, pipeline string, event string) error {
+	if !strings.HasPrefix(event, registryEventPrefix) {
+		return fmt.Errorf("failed to update registry trigger: %q is not a registry event", event)
+	}
+	p, err := t.codefresh.Pipelines().GetWithContext(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	resp, err := t.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/hermes/triggers/%s/%s", url.PathEscape(event), url.PathEscape(p.Metadata.ID)),
+		method: method,
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (t *triggers) listRegistry(ctx context.Context, pipelineID string) ([]RegistryTrigger, error) {
+	all := []RegistryTrigger{}
+	resp, err := t.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/hermes/triggers/pipeline/%s", url.PathEscape(pipelineID)),
+		method: "GET",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := t.codefresh.decodeResponseInto(resp, &all); err != nil {
+		return nil, err
+	}
+	registry := []RegistryTrigger{}
+	for _, trigger := range all {
+		if strings.HasPrefix(trigger.Event, registryEventPrefix) {
+			registry = append(registry, trigger)
+		}
+	}
+	return registry, nil
+}
+
+// Fire - starts a build the way the named git trigger would for event,
+// fails without running anything when the trigger is disabled or would ignore the event
+func (t *triggers) Fire(ctx context.Context, pipeline string, name string, event *GitEvent) (*RunResult, error) {
+	p, err := t.codefresh.Pipelines().GetWithContext(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	trigger, err := storedTrigger(p, name)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		event = &GitEvent{}
+	}
+	if err := trigger.Accepts(event); err != nil {
+		return nil, fmt.Errorf("failed to fire trigger %s: %w", name, err)
+	}
+	variables := map[string]string{}
+	for _, v := range trigger.Variables {
+		if !v.Encrypted {
+			variables[v.Key] = v.Value
+		}
+	}
+	for k, v := range event.Variables {
+		variables[k] = v
+	}
+	return t.codefresh.Pipelines().RunWithContext(ctx, pipeline, &RunOptions{
+		Branch:             event.Branch,
+		SHA:                event.SHA,
+		TriggerID:          trigger.ID,
+		Variables:          variables,
+		Contexts:           trigger.Contexts,
+		RuntimeEnvironment: runtimeEnvironmentName(trigger.RuntimeEnvironment),
+	})
+}
+
+// Accepts returns why the trigger would ignore the event, or nil when it would start a build
+func (t *Trigger) Accepts(event *GitEvent) error {
+	if t.Disabled != nil && *t.Disabled {
+		return fmt.Errorf("trigger is disabled")
+	}
+	eventType := event.Type
+	if eventType == "" {
+		eventType = "push.heads"
+	}
+	if len(t.Events) > 0 && !containsString(t.Events, eventType) {
+		return fmt.Errorf("trigger does not listen to %s events", eventType)
+	}
+	if t.BranchRegex != "" && event.Branch != "" {
+		re, err := compileJSRegexp(t.BranchRegex)
+		if err != nil {
+			return fmt.Errorf("invalid branch regex %q: %w", t.BranchRegex, err)
+		}
+		if !re.MatchString(event.Branch) {
+			return fmt.Errorf("branch %s does not match %s", event.Branch, t.BranchRegex)
+		}
+	}
+	return nil
+}
+
+// compileJSRegexp compiles a regex stored the way Codefresh stores them, in JavaScript
+// literal form (e.g. /^master$/gi), a plain pattern is compiled as is
+func compileJSRegexp(pattern string) (*regexp.Regexp, error) {
+	m := jsRegexpLiteral.FindStringSubmatch(pattern)
+	if m == nil {
+		return regexp.Compile(pattern)
+	}
+	body, flags := m[1], ""
+	for _, f := range m[2] {
+		// g, u and y change how JavaScript iterates matches, not what matches
+		if strings.ContainsRune("ims", f) && !strings.ContainsRune(flags, f) {
+			flags += string(f)
+		}
+	}
+	if flags != "" {
+		body = "(?" + flags + ")" + body
+	}
+	return regexp.Compile(body)
+}
+
+func findTrigger(p *Pipeline, name string) int {
+	for i, trigger := range p.Spec.Triggers {
+		if trigger.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func findCronTrigger(p *Pipeline, name string) int {
+	for i, trigger := range p.Spec.CronTriggers {
+		if trigger.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func storedTrigger(p *Pipeline, name string) (*Trigger, error) {
+	i := findTrigger(p, name)
+	if i < 0 {
+		return nil, fmt.Errorf("pipeline %s has no git trigger named %s", p.Metadata.Name, name)
+	}
+	return &p.Spec.Triggers[i], nil
+}
+
+func storedCronTrigger(p *Pipeline, name string) (*CronTrigger, error) {
+	i := findCronTrigger(p, name)
+	if i < 0 {
+		return nil, fmt.Errorf("pipeline %s has no cron trigger named %s", p.Metadata.Name, name)
+	}
+	return &p.Spec.CronTriggers[i], nil
+}
+
+func runtimeEnvironmentName(re *RuntimeEnvironmentOverride) string {
+	if re == nil {
+		return ""
+	}
+	return re.Name
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package codefresh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func triggersServer(t *testing.T, stored *Pipeline, calls *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, r.Method+" "+r.URL.EscapedPath())
+		switch {
+		case r.URL.Path == "/api/pipelines/proj/build" && r.Method == "GET":
+			json.NewEncoder(w).Encode(stored)
+		case r.URL.Path == "/api/pipelines/5f1d" && r.Method == "PUT":
+			body := &Pipeline{}
+			json.NewDecoder(r.Body).Decode(body)
+			for i := range body.Spec.Triggers {
+				if body.Spec.Triggers[i].ID == "" {
+					body.Spec.Triggers[i].ID = "t" + body.Spec.Triggers[i].Name
+				}
+			}
+			*stored = *body
+			json.NewEncoder(w).Encode(body)
+		case r.URL.Path == "/api/hermes/triggers/pipeline/5f1d":
+			w.Write([]byte(`[{"event":"registry:dockerhub:codefresh:go-sdk:push:acc","pipeline":"5f1d"},{"event":"cron:codefresh:0 2 * * *:nightly:acc","pipeline":"5f1d"}]`))
+		case r.URL.Path == "/api/pipelines/run/proj/build":
+			body := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, "tpush", body["trigger"])
+			assert.Equal(t, "main", body["branch"])
+			assert.Equal(t, map[string]interface{}{"A": "1", "B": "2"}, body["variables"])
+			w.Write([]byte(`"b1"`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+}
+
+func TestTriggersEnableSendsDisabledFalse(t *testing.T) {
+	var put map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			json.NewDecoder(r.Body).Decode(&put)
+		}
+		w.Write([]byte(`{"metadata":{"id":"5f1d","name":"proj/build"},"spec":{"triggers":[{"name":"push","disabled":true}],"cronTriggers":[{"name":"nightly","disabled":true}]}}`))
+	}))
+	defer server.Close()
+	api := New(&ClientOptions{Host: server.URL}).Triggers()
+
+	assert.NoError(t, api.Enable(context.Background(), "proj/build", "push"))
+	spec := put["spec"].(map[string]interface{})
+	assert.Equal(t, false, spec["triggers"].([]interface{})[0].(map[string]interface{})["disabled"])
+	assert.Equal(t, true, spec["cronTriggers"].([]interface{})[0].(map[string]interface{})["disabled"])
+
+	assert.NoError(t, api.Enable(context.Background(), "proj/build", "nightly"))
+	spec = put["spec"].(map[string]interface{})
+	assert.Equal(t, false, spec["cronTriggers"].([]interface{})[0].(map[string]interface{})["disabled"])
+}
+
+func TestTriggers(t *testing.T) {
+	stored := &Pipeline{}
+	stored.Metadata.ID = "5f1d"
+	stored.Metadata.Name = "proj/build"
+	calls := []string{}
+	server := triggersServer(t, stored, &calls)
+	defer server.Close()
+	api := New(&ClientOptions{Host: server.URL}).Triggers()
+	ctx := context.Background()
+
+	added, err := api.AddGit(ctx, "proj/build", &Trigger{
+		Name:        "push",
+		Events:      []string{"push.heads"},
+		BranchRegex: "/^main$/gi",
+		Variables:   []Variable{{Key: "A", Value: "1"}, {Key: "S", Value: "*****", Encrypted: true}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "tpush", added.ID)
+
+	_, err = api.AddGit(ctx, "proj/build", &Trigger{Name: "push"})
+	assert.Error(t, err)
+
+	_, err = api.AddCron(ctx, "proj/build", &CronTrigger{Name: "nightly", Expression: "0 2 * * *"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, api.Disable(ctx, "proj/build", "nightly"))
+	assert.True(t, *stored.Spec.CronTriggers[0].Disabled)
+	assert.NoError(t, api.Enable(ctx, "proj/build", "nightly"))
+	assert.False(t, *stored.Spec.CronTriggers[0].Disabled)
+
+	list, err := api.List(ctx, "proj/build")
+	assert.NoError(t, err)
+	assert.Len(t, list.Git, 1)
+	assert.Len(t, list.Cron, 1)
+	assert.Equal(t, []RegistryTrigger{{Event: "registry:dockerhub:codefresh:go-sdk:push:acc", Pipeline: "5f1d"}}, list.Registry)
+
+	_, err = api.Fire(ctx, "proj/build", "push", &GitEvent{Branch: "feature"})
+	assert.EqualError(t, err, "failed to fire trigger push: branch feature does not match /^main$/gi")
+	res, err := api.Fire(ctx, "proj/build", "push", &GitEvent{Branch: "main", Variables: map[string]string{"B": "2"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "b1", res.ID)
+
+	calls = calls[:0]
+	assert.NoError(t, api.AddRegistry(ctx, "proj/build", "registry:dockerhub:codefresh:go-sdk:push:acc"))
+	assert.Equal(t, "POST /api/hermes/triggers/registry:dockerhub:codefresh:go-sdk:push:acc/5f1d", calls[1])
+	assert.Error(t, api.AddRegistry(ctx, "proj/build", "cron:nightly"))
+
+	calls = calls[:0]
+	assert.NoError(t, api.Disable(ctx, "proj/build", "registry:dockerhub:codefresh:go-sdk:push:acc"))
+	assert.Equal(t, "DELETE /api/hermes/triggers/registry:dockerhub:codefresh:go-sdk:push:acc/5f1d", calls[len(calls)-1])
+	assert.Error(t, api.Disable(ctx, "proj/build", "registry:dockerhub:codefresh:other:push:acc"))
+	calls = calls[:0]
+	assert.NoError(t, api.Enable(ctx, "proj/build", "registry:dockerhub:codefresh:go-sdk:push:acc"))
+	assert.Equal(t, "POST /api/hermes/triggers/registry:dockerhub:codefresh:go-sdk:push:acc/5f1d", calls[len(calls)-1])
+
+	assert.NoError(t, api.Delete(ctx, "proj/build", "push"))
+	assert.Empty(t, stored.Spec.Triggers)
+	assert.Error(t, api.Delete(ctx, "proj/build", "push"))
+}
+
+func TestTriggerAcceptsBranchRegex(t *testing.T) {
+	tests := []struct {
+		regex  string
+		branch string
+		match  bool
+	}{
+		{"/^main$/gi", "main", true},
+		{"/^main$/gi", "MAIN", true},
+		{"/^main$/gi", "feature", false},
+		{"/^master$/", "Master", false},
+		{"/.*/gi", "anything", true},
+		{"/^release\\/.+/", "release/1.0", true},
+		{"^main$", "main", true},
+		{"^main$", "MAIN", false},
+	}
+	for _, tt := range tests {
+		err := (&Trigger{BranchRegex: tt.regex}).Accepts(&GitEvent{Branch: tt.branch})
+		assert.Equal(t, tt.match, err == nil, "%s on %s: %v", tt.regex, tt.branch, err)
+	}
+}
@@ -1,6 +1,9 @@
 package codefresh
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type (
 	// AuthOptions
@@ -10,18 +13,58 @@ type (
 		Token string
 	}
 
+	// RetryOptions
+	RetryOptions struct {
+
+		// MaxAttempts - total number of attempts including the first one, values lower than 2 disable retries
+		MaxAttempts int
+
+		// InitialBackoff - delay before the first retry, doubled on every following attempt (default 500ms)
+		InitialBackoff time.Duration
+
+		// MaxBackoff - upper bound for a single delay, Retry-After values are not capped (default 30s)
+		MaxBackoff time.Duration
+
+		// RetryNonIdempotent - retry POST and PATCH requests as well, GraphQL queries are always retried
+		RetryNonIdempotent bool
+
+		// RetryableStatusCodes - response codes that trigger a retry (default 429, 502, 503, 504)
+		RetryableStatusCodes []int
+	}
+
+	// RateLimitOptions
+	RateLimitOptions struct {
+
+		// RequestsPerSecond - sustained request rate, 0 disables rate limiting
+		RequestsPerSecond float64
+
+		// Burst - number of requests allowed above the sustained rate (default 1)
+		Burst int
+
+		// MaxInFlight - maximum number of concurrent requests, 0 means unlimited
+		MaxInFlight int
+	}
+
 	// Options
 	ClientOptions struct {
-		Auth   AuthOptions
-		Debug  bool
-		Host   string
-		Client *http.Client
+		Auth      AuthOptions
+		Debug     bool
+		Host      string
+		Client    *http.Client
+		Retry     *RetryOptions
+		RateLimit *RateLimitOptions
+		// Middlewares - applied to every REST and GraphQL request, in order
+		Middlewares []Middleware
+		// Logger - receives request/response logs when Debug is set (default stderr)
+		Logger Logger
 	}
 
 	codefresh struct {
-		token  string
-		host   string
-		client *http.Client
+		token   string
+		host    string
+		client  *http.Client
+		retry   *retryPolicy
+		limiter *limiter
 	}
 
 	requestOptions struct {
@@ -29,5 +72,7 @@ type (
 		method string
 		body   interface{}
 		qs     interface{}
+		// idempotent - the request is safe to retry regardless of its method
+		idempotent bool
 	}
 )
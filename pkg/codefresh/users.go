@@ -2,7 +2,6 @@ package codefresh
 
 import (
 	"context"
-	"fmt"
 )
 
 type (
@@ -44,9 +43,6 @@ func (u *users) GetCurrent(ctx context.Context) (*User, error) {
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf(resp.Status)
-	}
 
 	if err := u.codefresh.decodeResponseInto(resp, &result); err != nil {
 		return nil, err
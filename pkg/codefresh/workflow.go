@@ -1,15 +1,33 @@
 package codefresh
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"time"
 )
 
 type (
 	IWorkflowAPI interface {
 		WaitForStatus(string, string, time.Duration, time.Duration) error
+		WaitForStatusWithContext(ctx context.Context, id string, status string, interval time.Duration, timeout time.Duration) error
+		WaitForCompletionWithContext(ctx context.Context, id string, opt *WaitOptions) (*WaitResult, error)
 		Get(string) (*Workflow, error)
+		GetWithContext(ctx context.Context, id string) (*Workflow, error)
+		ListPageWithContext(ctx context.Context, opt *WorkflowListOptions) (*WorkflowList, error)
+		ListAllWithContext(ctx context.Context, opt *WorkflowListOptions) ([]*Workflow, error)
+		ForEachWithContext(ctx context.Context, opt *WorkflowListOptions, fn func(*Workflow) error) error
+		TerminateWithContext(ctx context.Context, id string) error
+		RestartWithContext(ctx context.Context, id string, opt *RestartOptions) (*RunResult, error)
+		ApproveWithContext(ctx context.Context, id string) error
+		DenyWithContext(ctx context.Context, id string) error
+		AnnotateWithContext(ctx context.Context, id string, annotations map[string]string) error
+		StepsWithContext(ctx context.Context, id string) (*BuildSteps, error)
+		TestReportsWithContext(ctx context.Context, id string) ([]*TestReport, error)
+		DownloadTestReportWithContext(ctx context.Context, report *TestReport, out io.Writer) error
+		ImagesWithContext(ctx context.Context, id string) ([]*BuildImage, error)
+		AnnotationsWithContext(ctx context.Context, id string) (map[string]string, error)
 	}
 
 	workflow struct {
@@ -24,16 +42,89 @@ type (
 		Created            time.Time `json:"created"`
 		Updated            time.Time `json:"updated"`
 		Finished           time.Time `json:"finished"`
+		// Started - when the build left the queue, zero while pending
+		Started time.Time `json:"started"`
+
+		PipelineID   string `json:"pipeline"`
+		PipelineName string `json:"pipelineName"`
+		// Trigger - what started the build: build (manual or API), webhook, cron, ...
+		Trigger string `json:"trigger"`
+		// Initiator - user name of who started the build
+		Initiator string `json:"userName"`
+
+		RepoOwner     string `json:"repoOwner"`
+		RepoName      string `json:"repoName"`
+		Branch        string `json:"branchName"`
+		Revision      string `json:"revision"`
+		CommitMessage string `json:"commitMessage"`
+		CommitAuthor  string `json:"commitAuthor"`
+		CommitURL     string `json:"commitURL"`
+
+		RuntimeEnvironment WorkflowRuntimeEnvironment `json:"runtimeEnvironment"`
+	}
+
+	WorkflowRuntimeEnvironment struct {
+		Name string `json:"name"`
+	}
+
+	// WorkflowListOptions - filters for listing builds, Limit is used as the page size when iterating
+	WorkflowListOptions struct {
+		Limit int `url:"limit,omitempty"`
+		// Page - 1-based page number
+		Page        int      `url:"page,omitempty"`
+		PipelineIDs []string `url:"pipeline,omitempty"`
+		Branch      string   `url:"branchName,omitempty"`
+		Status      []string `url:"status,omitempty"`
+		// Trigger - trigger types, e.g. build, webhook or cron
+		Trigger   []string `url:"trigger,omitempty"`
+		Initiator string   `url:"userName,omitempty"`
+		// From, To - range of the build creation time
+		From time.Time `url:"startDate,omitempty"`
+		To   time.Time `url:"endDate,omitempty"`
+		// Annotations - key=value pairs the builds must be annotated with
+		Annotations []string `url:"annotation,omitempty"`
+		// Sort - field to sort by, prefixed with "-" for descending order (default -created)
+		Sort string `url:"sort,omitempty"`
+	}
+
+	// WorkflowList - a single page of builds
+	WorkflowList struct {
+		Workflows []*Workflow
+		// Total - number of builds matching the filters
+		Total int
+	}
+
+	getWorkflowsResponse struct {
+		Workflows struct {
+			Docs  []*Workflow `json:"docs"`
+			Total int         `json:"total"`
+		} `json:"workflows"`
 	}
 )
 
+// Build statuses
+const (
+	WorkflowStatusPending         = "pending"
+	WorkflowStatusElected         = "elected"
+	WorkflowStatusRunning         = "running"
+	WorkflowStatusPendingApproval = "pending-approval"
+	WorkflowStatusSuccess         = "success"
+	WorkflowStatusError           = "error"
+	WorkflowStatusTerminated      = "terminated"
+)
+
 func newWorkflowAPI(codefresh *codefresh) IWorkflowAPI {
 	return &workflow{codefresh}
 }
 
+// Deprecated: use GetWithContext
 func (w *workflow) Get(id string) (*Workflow, error) {
+	return w.GetWithContext(context.Background(), id)
+}
+
+func (w *workflow) GetWithContext(ctx context.Context, id string) (*Workflow, error) {
 	wf := &Workflow{}
-	resp, err := w.codefresh.requestAPI(&requestOptions{
+	resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
 		path:   fmt.Sprintf("/api/builds/%s", id),
 		method: "GET",
 	})
@@ -49,20 +140,102 @@ func (w *workflow) Get(id string) (*Workflow, error) {
 	return wf, nil
 }
 
-func (w *workflow) WaitForStatus(id string, status string, interval time.Duration, timeout time.Duration) error {
-	err := waitFor(interval, timeout, func() (bool, error) {
+// ListPageWithContext - returns a single page of builds, newest first, and the total amount matching the filters
+func (w *workflow) ListPageWithContext(ctx context.Context, opt *WorkflowListOptions) (*WorkflowList, error) {
+	if opt == nil {
+		opt = &WorkflowListOptions{}
+	}
+	r := &getWorkflowsResponse{}
+	resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   "/api/workflow",
+		method: "GET",
+		qs:     opt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = w.codefresh.decodeResponseInto(resp, r)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkflowList{Workflows: r.Workflows.Docs, Total: r.Workflows.Total}, nil
+}
 
-		wf := &Workflow{}
-		resp, err := w.codefresh.requestAPI(&requestOptions{
-			path:   fmt.Sprintf("/api/builds/%s", id),
-			method: "GET",
-		})
-		// failed in api call
+// ListAllWithContext - returns every build matching the filters, walking all pages
+func (w *workflow) ListAllWithContext(ctx context.Context, opt *WorkflowListOptions) ([]*Workflow, error) {
+	workflows := []*Workflow{}
+	err := w.ForEachWithContext(ctx, opt, func(wf *Workflow) error {
+		workflows = append(workflows, wf)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workflows, nil
+}
+
+// ForEachWithContext - calls fn for every build matching the filters, fetching pages lazily
+func (w *workflow) ForEachWithContext(ctx context.Context, opt *WorkflowListOptions, fn func(*Workflow) error) error {
+	filters := WorkflowListOptions{}
+	if opt != nil {
+		filters = *opt
+	}
+	if filters.Limit <= 0 {
+		filters.Limit = defaultPageSize
+	}
+	page, offset := 1, 0
+	if filters.Page > 1 {
+		page, offset = filters.Page, (filters.Page-1)*filters.Limit
+	}
+	// the endpoint is paged rather than offset based, pages are counted apart from the items
+	// since the server may return fewer items per page than requested
+	return paginateOffset(ctx, offset, filters.Limit, func(ctx context.Context, offset int, limit int) (*offsetPage, error) {
+		filters.Page = page
+		filters.Limit = limit
+		page++
+		list, err := w.ListPageWithContext(ctx, &filters)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-		err = w.codefresh.decodeResponseInto(resp, wf)
-		// failed to decode
+		return &offsetPage{count: len(list.Workflows), total: list.Total, item: func(i int) error {
+			return fn(list.Workflows[i])
+		}}, nil
+	})
+}
+
+// QueueDuration - how long the build waited before starting, up to now while still pending
+func (wf *Workflow) QueueDuration() time.Duration {
+	if wf.Created.IsZero() {
+		return 0
+	}
+	if wf.Started.IsZero() {
+		return time.Since(wf.Created)
+	}
+	return wf.Started.Sub(wf.Created)
+}
+
+// Duration - how long the build ran, up to now while still running
+func (wf *Workflow) Duration() time.Duration {
+	if wf.Started.IsZero() {
+		return 0
+	}
+	if wf.Finished.IsZero() {
+		return time.Since(wf.Started)
+	}
+	return wf.Finished.Sub(wf.Started)
+}
+
+// Deprecated: use WaitForCompletionWithContext
+func (w *workflow) WaitForStatus(id string, status string, interval time.Duration, timeout time.Duration) error {
+	return w.WaitForStatusWithContext(context.Background(), id, status, interval, timeout)
+}
+
+// Deprecated: use WaitForCompletionWithContext
+func (w *workflow) WaitForStatusWithContext(ctx context.Context, id string, status string, interval time.Duration, timeout time.Duration) error {
+	err := waitFor(ctx, interval, timeout, func() (bool, error) {
+
+		wf, err := w.GetWithContext(ctx, id)
+		// failed in api call
 		if err != nil {
 			return false, err
 		}
@@ -79,16 +252,23 @@ func (w *workflow) WaitForStatus(id string, status string, interval time.Duratio
 	return nil
 }
 
-func waitFor(interval time.Duration, timeout time.Duration, execution func() (bool, error)) error {
-	t := time.After(timeout)
-	tick := time.Tick(interval)
+func waitFor(ctx context.Context, interval time.Duration, timeout time.Duration, execution func() (bool, error)) error {
+	// NewTimer and NewTicker rather than time.After and time.Tick, which are never
+	// released when the caller returns early
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
 	// Keep trying until we're timed out or got a result or got an error
 	for {
 		select {
 		// Got a timeout! fail with a timeout error
-		case <-t:
+		case <-t.C:
 			return errors.New("timed out")
-		case <-tick:
+		// Caller gave up
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
 			ok, err := execution()
 			if err != nil {
 				return err
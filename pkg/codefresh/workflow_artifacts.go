@@ -0,0 +1,143 @@
+package codefresh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+type (
+	// TestReport is a test report bundle uploaded by a test-reporting step of a build
+	TestReport struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		// Type - the format of the bundle, e.g. allure or junit
+		Type    string    `json:"type"`
+		URL     string    `json:"url"`
+		Size    int64     `json:"size"`
+		Created time.Time `json:"created"`
+	}
+
+	// BuildImage is the metadata of an image a build produced
+	BuildImage struct {
+		ID         string      `json:"internalImageId"`
+		Name       string      `json:"imageDisplayName"`
+		SHA        string      `json:"sha"`
+		Size       int64       `json:"size"`
+		Created    time.Time   `json:"created"`
+		Branch     string      `json:"branch"`
+		Commit     string      `json:"commit"`
+		Dockerfile string      `json:"dockerFile"`
+		Tags       []*ImageTag `json:"tags"`
+	}
+
+	// ImageTag is a tag of an image in a registry
+	ImageTag struct {
+		Tag      string    `json:"tag"`
+		Registry string    `json:"registry"`
+		Created  time.Time `json:"created"`
+	}
+
+	imageList struct {
+		Docs []*BuildImage `json:"docs"`
+	}
+
+	annotation struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+)
+
+// Spec - decodes the resolved yaml the build ran with
+func (w *Workflow) Spec() (*PipelineSpec, error) {
+	if w.UserYamlDescriptor == "" {
+		return nil, fmt.Errorf("build %s has no yaml", w.ID)
+	}
+	spec := &PipelineSpec{}
+	if err := yaml.Unmarshal([]byte(w.UserYamlDescriptor), spec); err != nil {
+		return nil, fmt.Errorf("failed to decode yaml of build %s: %w", w.ID, err)
+	}
+	return spec, nil
+}
+
+// TestReportsWithContext - the test report bundles of a build
+func (w *workflow) TestReportsWithContext(ctx context.Context, id string) ([]*TestReport, error) {
+	resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/workflow/%s/test-reports", url.PathEscape(id)),
+		method: "GET",
+	})
+	if err != nil {
+		return nil, err
+	}
+	reports := []*TestReport{}
+	if err := w.codefresh.decodeResponseInto(resp, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// DownloadTestReportWithContext - writes the bundle of a test report to out
+func (w *workflow) DownloadTestReportWithContext(ctx context.Context, report *TestReport, out io.Writer) error {
+	if report.URL == "" {
+		return fmt.Errorf("test report %s has no download url", report.Name)
+	}
+	resp, err := w.codefresh.requestURL(ctx, report.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fmt.Errorf("failed to download test report %s: %w", report.Name, err)
+	}
+	return nil
+}
+
+// ImagesWithContext - the images a build produced
+func (w *workflow) ImagesWithContext(ctx context.Context, id string) ([]*BuildImage, error) {
+	resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   "/api/images",
+		method: "GET",
+		qs:     map[string]string{"workflowId": id},
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := &imageList{}
+	if err := w.codefresh.decodeResponseInto(resp, list); err != nil {
+		return nil, err
+	}
+	if list.Docs == nil {
+		list.Docs = []*BuildImage{}
+	}
+	return list.Docs, nil
+}
+
+// AnnotationsWithContext - the annotations of a build, values that are not strings are returned as JSON
+func (w *workflow) AnnotationsWithContext(ctx context.Context, id string) (map[string]string, error) {
+	resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   "/api/annotations",
+		method: "GET",
+		qs:     map[string]string{"entityId": id, "entityType": "build"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := []*annotation{}
+	if err := w.codefresh.decodeResponseInto(resp, &list); err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(list))
+	for _, a := range list {
+		var s string
+		if err := json.Unmarshal(a.Value, &s); err != nil {
+			s = string(a.Value)
+		}
+		res[a.Key] = s
+	}
+	return res, nil
+}
@@ -0,0 +1,66 @@
+package codefresh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowArtifacts(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/workflow/b1/test-reports":
+			fmt.Fprintf(w, `[{"id":"r1","name":"unit","type":"junit","url":"%s/reports/r1.tar.gz"}]`, server.URL)
+		case "/reports/r1.tar.gz":
+			w.Write([]byte("bundle"))
+		case "/api/images":
+			assert.Equal(t, "b1", r.URL.Query().Get("workflowId"))
+			w.Write([]byte(`{"docs":[{"internalImageId":"i1","imageDisplayName":"codefresh/app","sha":"sha256:abc","tags":[{"tag":"1.0","registry":"r.cfcr.io"}]}]}`))
+		case "/api/annotations":
+			assert.Equal(t, "b1", r.URL.Query().Get("entityId"))
+			assert.Equal(t, "build", r.URL.Query().Get("entityType"))
+			w.Write([]byte(`[{"key":"ticket","value":"OPS-1"},{"key":"coverage","value":81.5},{"key":"approved","value":true}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := New(&ClientOptions{Host: server.URL}).Workflows()
+	ctx := context.Background()
+
+	reports, err := api.TestReportsWithContext(ctx, "b1")
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "junit", reports[0].Type)
+	out := &bytes.Buffer{}
+	assert.NoError(t, api.DownloadTestReportWithContext(ctx, reports[0], out))
+	assert.Equal(t, "bundle", out.String())
+	assert.Error(t, api.DownloadTestReportWithContext(ctx, &TestReport{Name: "missing", URL: server.URL + "/reports/missing"}, out))
+
+	images, err := api.ImagesWithContext(ctx, "b1")
+	assert.NoError(t, err)
+	assert.Equal(t, "codefresh/app", images[0].Name)
+	assert.Equal(t, "1.0", images[0].Tags[0].Tag)
+
+	annotations, err := api.AnnotationsWithContext(ctx, "b1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"ticket": "OPS-1", "coverage": "81.5", "approved": "true"}, annotations)
+}
+
+func TestWorkflowSpec(t *testing.T) {
+	wf := &Workflow{ID: "b1", UserYamlDescriptor: stepsTestYAML}
+	spec, err := wf.Spec()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build", "test"}, spec.Stages)
+	assert.Equal(t, "codefresh/app", spec.Steps.Get("image").ImageName)
+	assert.Len(t, spec.Steps.Get("tests").Steps, 2)
+
+	_, err = (&Workflow{ID: "b2"}).Spec()
+	assert.EqualError(t, err, "build b2 has no yaml")
+}
@@ -0,0 +1,148 @@
+package codefresh
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type (
+	// RestartOptions - how a finished build is restarted
+	RestartOptions struct {
+		// FromFailedStep - reuse the results of the steps that passed and resume from the failed one
+		FromFailedStep bool
+	}
+
+	annotationRequest struct {
+		EntityID   string `json:"entityId"`
+		EntityType string `json:"entityType"`
+		Key        string `json:"key"`
+		Value      string `json:"value"`
+	}
+)
+
+var (
+	activeStatuses   = []string{WorkflowStatusPending, WorkflowStatusElected, WorkflowStatusRunning, WorkflowStatusPendingApproval}
+	finishedStatuses = []string{WorkflowStatusSuccess, WorkflowStatusError, WorkflowStatusTerminated}
+	failedStatuses   = []string{WorkflowStatusError, WorkflowStatusTerminated}
+	approvalStatuses = []string{WorkflowStatusPendingApproval}
+)
+
+// TerminateWithContext - stops a build that has not finished yet
+func (w *workflow) TerminateWithContext(ctx context.Context, id string) error {
+	wf, err := w.getInStatus(ctx, id, "terminate", activeStatuses)
+	if err != nil {
+		return err
+	}
+	resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/progress/%s/terminate", url.PathEscape(wf.Progress)),
+		method: "POST",
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// RestartWithContext - starts a new build with the same parameters as a finished one,
+// FromFailedStep is only possible for builds that failed
+func (w *workflow) RestartWithContext(ctx context.Context, id string, opt *RestartOptions) (*RunResult, error) {
+	if opt == nil {
+		opt = &RestartOptions{}
+	}
+	allowed := finishedStatuses
+	if opt.FromFailedStep {
+		allowed = failedStatuses
+	}
+	if _, err := w.getInStatus(ctx, id, "restart", allowed); err != nil {
+		return nil, err
+	}
+	qs := map[string]string{}
+	if opt.FromFailedStep {
+		qs["restartFromFailedSteps"] = "true"
+	}
+	resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path: fmt.Sprintf("/api/builds/rebuild/%s", url.PathEscape(id)),
+		// POST so a retry never starts a second build
+		method: "POST",
+		qs:     qs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := w.codefresh.getBodyAsString(resp)
+	if err != nil {
+		return nil, err
+	}
+	newID := strings.Replace(strings.TrimSpace(res), "\"", "", -1)
+	return &RunResult{
+		ID:  newID,
+		URL: fmt.Sprintf("%s/build/%s", w.codefresh.host, newID),
+	}, nil
+}
+
+// ApproveWithContext - approves the pending approval step of a build
+func (w *workflow) ApproveWithContext(ctx context.Context, id string) error {
+	return w.decideApproval(ctx, id, "approve")
+}
+
+// DenyWithContext - denies the pending approval step of a build
+func (w *workflow) DenyWithContext(ctx context.Context, id string) error {
+	return w.decideApproval(ctx, id, "deny")
+}
+
+func (w *workflow) decideApproval(ctx context.Context, id string, decision string) error {
+	if _, err := w.getInStatus(ctx, id, decision, approvalStatuses); err != nil {
+		return err
+	}
+	resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
+		path:   fmt.Sprintf("/api/workflow/%s/pending-approval/%s", url.PathEscape(id), decision),
+		method: "POST",
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// AnnotateWithContext - sets key/value annotations on a build, existing keys are overwritten
+func (w *workflow) AnnotateWithContext(ctx context.Context, id string, annotations map[string]string) error {
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		resp, err := w.codefresh.requestAPIWithContext(ctx, &requestOptions{
+			path:   "/api/annotations",
+			method: "POST",
+			body: &annotationRequest{
+				EntityID:   id,
+				EntityType: "build",
+				Key:        k,
+				Value:      annotations[k],
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to annotate build %s with %s: %w", id, k, err)
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// getInStatus fetches the build and fails with a *WorkflowStateError when its status is not allowed
+func (w *workflow) getInStatus(ctx context.Context, id string, operation string, allowed []string) (*Workflow, error) {
+	wf, err := w.GetWithContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(allowed, wf.Status) {
+		return nil, &WorkflowStateError{ID: id, Operation: operation, Status: wf.Status, Allowed: allowed}
+	}
+	return wf, nil
+}
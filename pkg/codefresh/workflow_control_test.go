@@ -0,0 +1,58 @@
+package codefresh
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowControl(t *testing.T) {
+	status := "running"
+	calls := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/builds/b1" {
+			w.Write([]byte(`{"id":"b1","progress":"p1","status":"` + status + `"}`))
+			return
+		}
+		calls = append(calls, r.Method+" "+r.URL.RequestURI())
+		if r.URL.Path == "/api/builds/rebuild/b1" {
+			w.Write([]byte(`"b2"`))
+		}
+	}))
+	defer server.Close()
+	api := New(&ClientOptions{Host: server.URL}).Workflows()
+	ctx := context.Background()
+
+	assert.NoError(t, api.TerminateWithContext(ctx, "b1"))
+
+	err := api.ApproveWithContext(ctx, "b1")
+	assert.True(t, IsInvalidState(err))
+	assert.EqualError(t, err, "cannot approve build b1 in status running, expected one of: pending-approval")
+	_, err = api.RestartWithContext(ctx, "b1", nil)
+	assert.True(t, IsInvalidState(err))
+
+	status = WorkflowStatusPendingApproval
+	assert.NoError(t, api.ApproveWithContext(ctx, "b1"))
+	assert.NoError(t, api.DenyWithContext(ctx, "b1"))
+
+	status = WorkflowStatusSuccess
+	_, err = api.RestartWithContext(ctx, "b1", &RestartOptions{FromFailedStep: true})
+	assert.True(t, IsInvalidState(err))
+	status = WorkflowStatusError
+	res, err := api.RestartWithContext(ctx, "b1", &RestartOptions{FromFailedStep: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "b2", res.ID)
+
+	assert.NoError(t, api.AnnotateWithContext(ctx, "b1", map[string]string{"ticket": "OPS-1"}))
+
+	assert.Equal(t, []string{
+		"POST /api/progress/p1/terminate",
+		"POST /api/workflow/b1/pending-approval/approve",
+		"POST /api/workflow/b1/pending-approval/deny",
+		"POST /api/builds/rebuild/b1?restartFromFailedSteps=true",
+		"POST /api/annotations",
+	}, calls)
+}
@@ -0,0 +1,170 @@
+package codefresh
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const (
+	StepStatusPending         = "pending"
+	StepStatusRunning         = "running"
+	StepStatusSuccess         = "success"
+	StepStatusError           = "error"
+	StepStatusTerminated      = "terminated"
+	StepStatusSkipped         = "skipped"
+	StepStatusPendingApproval = "pending-approval"
+	StepStatusApproved        = "approved"
+	StepStatusDenied          = "denied"
+)
+
+type (
+	// BuildSteps is the step-level view of a build
+	BuildSteps struct {
+		Build *Workflow
+		// Steps - in the order the build reported them, including the system steps
+		// (e.g. initialization and cloning) that are not part of the pipeline yaml
+		Steps []*BuildStep
+	}
+
+	// BuildStep is the state of a single step, Type, Stage and Image come from the
+	// resolved yaml of the build and are empty for system steps
+	BuildStep struct {
+		Name     string
+		Title    string
+		Type     string
+		Stage    string
+		Image    string
+		Status   string
+		Started  time.Time
+		Finished time.Time
+		// ExitCode - nil while the step runs or when it did not run a container
+		ExitCode *int
+		// Retries - how many times the step was attempted again after its first attempt,
+		// the status, finish time and exit code are those of the last attempt
+		Retries int
+	}
+)
+
+var failedStepStatuses = []string{StepStatusError, StepStatusTerminated, StepStatusDenied}
+
+// StepsWithContext - the status and timing of every step of the build
+func (w *workflow) StepsWithContext(ctx context.Context, id string) (*BuildSteps, error) {
+	wf, err := w.GetWithContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := w.codefresh.getLogDocument(ctx, wf)
+	if err != nil {
+		return nil, err
+	}
+	res := &BuildSteps{Build: wf}
+	if doc == nil {
+		return res, nil
+	}
+	// a yaml that cannot be parsed only costs the type, stage and image of the steps
+	spec, _ := wf.Spec()
+	byName := map[string]*BuildStep{}
+	for _, s := range doc.Steps {
+		if step, ok := byName[s.Name]; ok {
+			step.Retries++
+			step.Title = s.Title
+			step.Status = s.Status
+			step.Finished = unixSeconds(s.FinishTimeStamp)
+			step.ExitCode = s.ExitCode
+			continue
+		}
+		step := &BuildStep{
+			Name:     s.Name,
+			Title:    s.Title,
+			Status:   s.Status,
+			Started:  unixSeconds(s.CreationTimeStamp),
+			Finished: unixSeconds(s.FinishTimeStamp),
+			ExitCode: s.ExitCode,
+		}
+		if spec != nil {
+			if def, stage := findStep(spec.Steps, s.Name, ""); def != nil {
+				step.Type = def.Type
+				if step.Type == "" {
+					step.Type = StepTypeFreestyle
+				}
+				step.Stage = stage
+				step.Image = def.Image
+				if def.Type == StepTypeBuild {
+					step.Image = def.ImageName
+				}
+			}
+		}
+		byName[s.Name] = step
+		res.Steps = append(res.Steps, step)
+	}
+	return res, nil
+}
+
+// Step returns the named step, or nil
+func (b *BuildSteps) Step(name string) *BuildStep {
+	for _, step := range b.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+	return nil
+}
+
+// Failed returns the steps that failed, in the order they started
+func (b *BuildSteps) Failed() []*BuildStep {
+	failed := []*BuildStep{}
+	for _, step := range b.Steps {
+		if step.Failed() {
+			failed = append(failed, step)
+		}
+	}
+	// stable so steps without a start time keep the order of the build
+	sort.SliceStable(failed, func(i, j int) bool {
+		return failed[i].Started.Before(failed[j].Started)
+	})
+	return failed
+}
+
+// FirstFailed returns the failed step that started first, or nil when no step failed
+func (b *BuildSteps) FirstFailed() *BuildStep {
+	failed := b.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return failed[0]
+}
+
+// Failed returns true when the step ended in error, was terminated or its approval was denied
+func (s *BuildStep) Failed() bool {
+	return containsString(failedStepStatuses, s.Status)
+}
+
+// Duration - how long the step ran, up to now for a running step and 0 before it started
+func (s *BuildStep) Duration() time.Duration {
+	if s.Started.IsZero() {
+		return 0
+	}
+	if s.Finished.IsZero() {
+		return time.Since(s.Started)
+	}
+	return s.Finished.Sub(s.Started)
+}
+
+// findStep looks the step up in the spec, including the steps nested in parallel steps,
+// which inherit the stage of their parent
+func findStep(steps Steps, name string, stage string) (*Step, string) {
+	for _, step := range steps {
+		s := step.Stage
+		if s == "" {
+			s = stage
+		}
+		if step.Name == name {
+			return step, s
+		}
+		if found, foundStage := findStep(step.Steps, name, s); found != nil {
+			return found, foundStage
+		}
+	}
+	return nil, ""
+}
@@ -0,0 +1,77 @@
+package codefresh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const stepsTestYAML = `version: "1.0"
+stages: [build, test]
+steps:
+  image:
+    type: build
+    stage: build
+    image_name: codefresh/app
+  tests:
+    type: parallel
+    stage: test
+    steps:
+      unit:
+        image: golang:1.16
+        commands: [go test ./...]
+      lint:
+        image: golangci/golangci-lint
+        commands: [golangci-lint run]
+`
+
+func TestWorkflowSteps(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/builds/b1":
+			json.NewEncoder(w).Encode(&Workflow{ID: "b1", Status: WorkflowStatusError, Progress: "p1", UserYamlDescriptor: stepsTestYAML})
+		case "/api/progress/p1":
+			fmt.Fprintf(w, `{"id":"p1","location":{"type":"db","url":"%s/api/progress/download/p1"}}`, server.URL)
+		case "/api/progress/download/p1":
+			w.Write([]byte(`{"steps":[
+				{"name":"Initializing Process","status":"success","creationTimeStamp":1590000000,"finishTimeStamp":1590000002},
+				{"name":"image","status":"success","creationTimeStamp":1590000002,"finishTimeStamp":1590000062,"exitCode":0},
+				{"name":"lint","status":"error","creationTimeStamp":1590000070,"finishTimeStamp":1590000075,"exitCode":1},
+				{"name":"unit","status":"error","creationTimeStamp":1590000063,"finishTimeStamp":1590000070,"exitCode":2},
+				{"name":"unit","status":"error","creationTimeStamp":1590000071,"finishTimeStamp":1590000090,"exitCode":3}]}`))
+		}
+	}))
+	defer server.Close()
+
+	res, err := New(&ClientOptions{Host: server.URL}).Workflows().StepsWithContext(context.Background(), "b1")
+	assert.NoError(t, err)
+	assert.Len(t, res.Steps, 4)
+
+	system := res.Step("Initializing Process")
+	assert.Equal(t, "", system.Type)
+	assert.Equal(t, 2*time.Second, system.Duration())
+
+	image := res.Step("image")
+	assert.Equal(t, StepTypeBuild, image.Type)
+	assert.Equal(t, "build", image.Stage)
+	assert.Equal(t, "codefresh/app", image.Image)
+
+	unit := res.Step("unit")
+	assert.Equal(t, StepTypeFreestyle, unit.Type)
+	assert.Equal(t, "test", unit.Stage)
+	assert.Equal(t, "golang:1.16", unit.Image)
+	assert.Equal(t, 1, unit.Retries)
+	assert.Equal(t, 3, *unit.ExitCode)
+	assert.Equal(t, 27*time.Second, unit.Duration())
+
+	assert.Equal(t, "unit", res.FirstFailed().Name)
+	assert.Len(t, res.Failed(), 2)
+	assert.False(t, image.Failed())
+}
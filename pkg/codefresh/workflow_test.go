@@ -0,0 +1,148 @@
+package codefresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForStatusWithContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"1","status":"running"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	err := cf.Workflows().WaitForStatusWithContext(ctx, "1", "success", 10*time.Millisecond, time.Minute)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestWorkflowListFilters(t *testing.T) {
+	pages := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/workflow", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "main", q.Get("branchName"))
+		assert.Equal(t, []string{"error", "terminated"}, q["status"])
+		assert.Equal(t, "2020-01-02T00:00:00Z", q.Get("startDate"))
+		assert.Equal(t, "", q.Get("endDate"))
+		pages = append(pages, q.Get("page"))
+		switch q.Get("page") {
+		case "1":
+			w.Write([]byte(`{"workflows":{"total":3,"docs":[
+				{"id":"1","status":"error","branchName":"main","userName":"jane","runtimeEnvironment":{"name":"re"},
+				 "created":"2020-01-02T10:00:00Z","started":"2020-01-02T10:00:30Z","finished":"2020-01-02T10:05:30Z"},
+				{"id":"2","status":"terminated"}]}}`))
+		default:
+			w.Write([]byte(`{"workflows":{"total":3,"docs":[{"id":"3","status":"error"}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	workflows, err := cf.Workflows().ListAllWithContext(context.Background(), &WorkflowListOptions{
+		Limit:  2,
+		Branch: "main",
+		Status: []string{WorkflowStatusError, WorkflowStatusTerminated},
+		From:   time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, pages)
+	assert.Len(t, workflows, 3)
+	assert.Equal(t, "jane", workflows[0].Initiator)
+	assert.Equal(t, "re", workflows[0].RuntimeEnvironment.Name)
+	assert.Equal(t, 30*time.Second, workflows[0].QueueDuration())
+	assert.Equal(t, 5*time.Minute, workflows[0].Duration())
+}
+
+func TestWorkflowListCappedPageSize(t *testing.T) {
+	// the server returns at most 20 builds per page whatever the requested limit
+	pages := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page int
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		pages = append(pages, r.URL.Query().Get("page"))
+		docs := []string{}
+		for i := (page - 1) * 20; i < page*20 && i < 50; i++ {
+			docs = append(docs, fmt.Sprintf(`{"id":"%d"}`, i))
+		}
+		fmt.Fprintf(w, `{"workflows":{"total":50,"docs":[%s]}}`, strings.Join(docs, ","))
+	}))
+	defer server.Close()
+
+	cf := New(&ClientOptions{Host: server.URL})
+	workflows, err := cf.Workflows().ListAllWithContext(context.Background(), &WorkflowListOptions{Limit: 50})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, pages)
+	ids := map[string]bool{}
+	for _, wf := range workflows {
+		ids[wf.ID] = true
+	}
+	assert.Len(t, workflows, 50)
+	assert.Len(t, ids, 50)
+}
+
+func TestWaitForCompletion(t *testing.T) {
+	statuses := []string{"pending", "running", "running", "error"}
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[polls]
+		if polls < len(statuses)-1 {
+			polls++
+		}
+		w.Write([]byte(`{"id":"1","status":"` + status + `"}`))
+	}))
+	defer server.Close()
+	cf := New(&ClientOptions{Host: server.URL})
+
+	seen := []string{}
+	res, err := cf.Workflows().WaitForCompletionWithContext(context.Background(), "1", &WaitOptions{
+		Statuses: []string{WorkflowStatusSuccess},
+		Interval: time.Millisecond,
+		Timeout:  time.Minute,
+		OnStatusChange: func(wf *Workflow) {
+			seen = append(seen, wf.Status)
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "error", res.Status)
+	assert.True(t, res.Terminal)
+	assert.False(t, res.Matched)
+	assert.False(t, res.Succeeded())
+	assert.Equal(t, []string{"pending", "running", "error"}, seen)
+}
+
+func TestWaitForCompletionStopsOnTargetStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"1","status":"pending-approval"}`))
+	}))
+	defer server.Close()
+	cf := New(&ClientOptions{Host: server.URL})
+
+	res, err := cf.Workflows().WaitForCompletionWithContext(context.Background(), "1", &WaitOptions{
+		Statuses: []string{WorkflowStatusPendingApproval},
+	})
+	assert.NoError(t, err)
+	assert.True(t, res.Matched)
+	assert.False(t, res.Terminal)
+
+	_, err = cf.Workflows().WaitForCompletionWithContext(context.Background(), "1", &WaitOptions{
+		Interval: time.Millisecond,
+		Timeout:  20 * time.Millisecond,
+	})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
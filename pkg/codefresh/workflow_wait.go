@@ -0,0 +1,110 @@
+package codefresh
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultWaitInterval    = 2 * time.Second
+	defaultWaitMaxInterval = 30 * time.Second
+	// waitBackoffFactor - how much the polling interval grows while the status does not change
+	waitBackoffFactor = 1.5
+)
+
+type (
+	// WaitOptions - how WaitForCompletionWithContext polls a build
+	WaitOptions struct {
+		// Statuses - also stop when the build reaches one of these, e.g. pending-approval
+		Statuses []string
+		// Interval - first polling interval, used again every time the status changes (default 2s)
+		Interval time.Duration
+		// MaxInterval - the interval grows up to this value while the status does not change (default 30s)
+		MaxInterval time.Duration
+		// Timeout - give up after this long, 0 relies on ctx only
+		Timeout time.Duration
+		// OnStatusChange - called with the build every time its status changes, including the first poll
+		OnStatusChange func(*Workflow)
+	}
+
+	// WaitResult is the build as seen by the last poll of WaitForCompletionWithContext
+	WaitResult struct {
+		Workflow *Workflow
+		Status   string
+		// Terminal - the build finished and its status will not change anymore
+		Terminal bool
+		// Matched - the status is one of WaitOptions.Statuses
+		Matched bool
+	}
+)
+
+// IsTerminalStatus returns true for build statuses that never change: success, error and terminated
+func IsTerminalStatus(status string) bool {
+	return containsString(finishedStatuses, status)
+}
+
+// Succeeded returns true when the build finished successfully
+func (r *WaitResult) Succeeded() bool {
+	return r.Status == WorkflowStatusSuccess
+}
+
+// WaitForCompletionWithContext - polls the build until it finishes or reaches one of opt.Statuses,
+// a build that finished in any status is a result, not an error
+func (w *workflow) WaitForCompletionWithContext(ctx context.Context, id string, opt *WaitOptions) (*WaitResult, error) {
+	if opt == nil {
+		opt = &WaitOptions{}
+	}
+	interval := opt.Interval
+	if interval <= 0 {
+		interval = defaultWaitInterval
+	}
+	maxInterval := opt.MaxInterval
+	if maxInterval < interval {
+		maxInterval = defaultWaitMaxInterval
+		if maxInterval < interval {
+			maxInterval = interval
+		}
+	}
+	if opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.Timeout)
+		defer cancel()
+	}
+
+	next := interval
+	last := ""
+	for {
+		wf, err := w.GetWithContext(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if wf.Status != last {
+			last = wf.Status
+			next = interval
+			if opt.OnStatusChange != nil {
+				opt.OnStatusChange(wf)
+			}
+		}
+		res := &WaitResult{
+			Workflow: wf,
+			Status:   wf.Status,
+			Terminal: IsTerminalStatus(wf.Status),
+			Matched:  containsString(opt.Statuses, wf.Status),
+		}
+		if res.Terminal || res.Matched {
+			return res, nil
+		}
+
+		t := time.NewTimer(next)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		next = time.Duration(float64(next) * waitBackoffFactor)
+		if next > maxInterval {
+			next = maxInterval
+		}
+	}
+}
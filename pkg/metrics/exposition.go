@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatPrometheus  Format = "prometheus"
+	FormatOpenMetrics Format = "openmetrics"
+)
+
+type (
+	// Format is an exposition format the exporter writes
+	Format string
+
+	family struct {
+		name    string
+		help    string
+		typ     string
+		samples []sample
+	}
+
+	sample struct {
+		suffix string
+		labels []label
+		value  float64
+	}
+
+	label struct {
+		name  string
+		value string
+	}
+)
+
+// ContentType - the Content-Type header of the format
+func (f Format) ContentType() string {
+	if f == FormatOpenMetrics {
+		return "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	}
+	return "text/plain; version=0.0.4; charset=utf-8"
+}
+
+// Write - writes the metrics of the last successful collection in the given format
+func (e *Exporter) Write(w io.Writer, format Format) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range e.families() {
+		writeFamily(bw, f, format)
+	}
+	if format == FormatOpenMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+func (e *Exporter) families() []*family {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	up := 0.0
+	if e.up {
+		up = 1
+	}
+	exporter := []*family{
+		{name: "codefresh_exporter_up", typ: "gauge", help: "Whether the last collection of builds succeeded.",
+			samples: []sample{{value: up}}},
+		{name: "codefresh_exporter_errors", typ: "counter", help: "Collections of builds that failed.",
+			samples: []sample{{suffix: "_total", value: float64(e.errors)}}},
+		{name: "codefresh_exporter_collection_duration_seconds", typ: "gauge", help: "How long the last collection of builds took.",
+			samples: []sample{{value: e.duration.Seconds()}}},
+	}
+	s := e.last
+	if s == nil {
+		return exporter
+	}
+
+	builds := &family{name: "codefresh_builds", typ: "gauge", help: "Builds created within the window by pipeline and status."}
+	keys := make([]buildKey, 0, len(s.builds))
+	for k := range s.builds {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pipeline != keys[j].pipeline {
+			return keys[i].pipeline < keys[j].pipeline
+		}
+		return keys[i].status < keys[j].status
+	})
+	for _, k := range keys {
+		builds.samples = append(builds.samples, sample{
+			labels: []label{{"pipeline", k.pipeline}, {"status", k.status}},
+			value:  float64(s.builds[k]),
+		})
+	}
+
+	pending := &family{name: "codefresh_builds_pending_approval", typ: "gauge", help: "Builds within the window waiting for an approval."}
+	for _, p := range sortedKeys(s.pendingApproval) {
+		pending.samples = append(pending.samples, sample{labels: []label{{"pipeline", p}}, value: float64(s.pendingApproval[p])})
+	}
+
+	ratio := &family{name: "codefresh_build_success_ratio", typ: "gauge", help: "Share of the builds finished within the window that succeeded."}
+	for _, p := range sortedKeys(s.finished) {
+		ratio.samples = append(ratio.samples, sample{
+			labels: []label{{"pipeline", p}},
+			value:  float64(s.succeeded[p]) / float64(s.finished[p]),
+		})
+	}
+
+	return append([]*family{
+		builds,
+		pending,
+		ratio,
+		histogramFamily("codefresh_build_duration_seconds", "Duration of the finished builds, since the exporter started.", e.durations),
+		histogramFamily("codefresh_build_queue_seconds", "Time the started builds waited before starting, since the exporter started.", e.queue),
+		{name: "codefresh_exporter_last_collection_timestamp_seconds", typ: "gauge", help: "When the served builds were collected.",
+			samples: []sample{{value: float64(s.at.UnixNano()) / 1e9}}},
+	}, exporter...)
+}
+
+func histogramFamily(name string, help string, hs map[string]*histogram) *family {
+	f := &family{name: name, typ: "histogram", help: help}
+	pipelines := make([]string, 0, len(hs))
+	for p := range hs {
+		pipelines = append(pipelines, p)
+	}
+	sort.Strings(pipelines)
+	for _, p := range pipelines {
+		h := hs[p]
+		for i, b := range h.bounds {
+			f.samples = append(f.samples, sample{
+				suffix: "_bucket",
+				labels: []label{{"pipeline", p}, {"le", formatFloat(b)}},
+				value:  float64(h.counts[i]),
+			})
+		}
+		f.samples = append(f.samples,
+			sample{suffix: "_bucket", labels: []label{{"pipeline", p}, {"le", "+Inf"}}, value: float64(h.count)},
+			sample{suffix: "_sum", labels: []label{{"pipeline", p}}, value: h.sum},
+			sample{suffix: "_count", labels: []label{{"pipeline", p}}, value: float64(h.count)},
+		)
+	}
+	return f
+}
+
+// writeFamily writes the metadata and samples of a family, counters are named after
+// their samples (with _total) in the Prometheus text format and without it in OpenMetrics
+func writeFamily(w *bufio.Writer, f *family, format Format) {
+	name := f.name
+	if f.typ == "counter" && format == FormatPrometheus {
+		name += "_total"
+	}
+	w.WriteString("# HELP " + name + " " + escape(f.help, false) + "\n")
+	w.WriteString("# TYPE " + name + " " + f.typ + "\n")
+	for _, s := range f.samples {
+		w.WriteString(f.name + s.suffix)
+		if len(s.labels) > 0 {
+			w.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				w.WriteString(l.name + "=\"" + escape(l.value, true) + "\"")
+			}
+			w.WriteByte('}')
+		}
+		w.WriteString(" " + formatFloat(s.value) + "\n")
+	}
+}
+
+func escape(s string, quoted bool) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	if quoted {
+		s = strings.Replace(s, `"`, `\"`, -1)
+	}
+	return s
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,239 @@
+// Package metrics exports the health of Codefresh builds in the Prometheus text
+// and OpenMetrics exposition formats
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultWindow   = 24 * time.Hour
+	// listPageSize - builds requested per page while collecting
+	listPageSize = 100
+)
+
+var (
+	// DefaultDurationBuckets - upper bounds in seconds of the build duration histogram
+	DefaultDurationBuckets = []float64{30, 60, 120, 300, 600, 1200, 1800, 3600, 7200}
+	// DefaultQueueBuckets - upper bounds in seconds of the queue time histogram
+	DefaultQueueBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+)
+
+type (
+	// Options - what the exporter collects and how often
+	Options struct {
+		// Interval - how often builds are listed by Run (default 1m)
+		Interval time.Duration
+		// Window - only builds created within this window are counted (default 24h)
+		Window time.Duration
+		// Pipelines - pipeline ids to collect, all pipelines when empty
+		Pipelines []string
+		// DurationBuckets, QueueBuckets - histogram upper bounds in seconds
+		DurationBuckets []float64
+		QueueBuckets    []float64
+	}
+
+	// Exporter periodically lists builds and serves the metrics of the last successful collection
+	Exporter struct {
+		api codefresh.IWorkflowAPI
+		opt Options
+		now func() time.Time
+
+		mu       sync.RWMutex
+		last     *snapshot
+		errors   int
+		up       bool
+		duration time.Duration
+		// histograms are cumulative, every build is observed once by ID
+		durations map[string]*histogram
+		queue     map[string]*histogram
+		// started, done - IDs of the builds of the last collection already in queue and durations
+		started map[string]bool
+		done    map[string]bool
+	}
+
+	snapshot struct {
+		at              time.Time
+		builds          map[buildKey]int
+		workflows       []*codefresh.Workflow
+		finished        map[string]int
+		succeeded       map[string]int
+		pendingApproval map[string]int
+	}
+
+	buildKey struct {
+		pipeline string
+		status   string
+	}
+
+	histogram struct {
+		bounds []float64
+		counts []int
+		count  int
+		sum    float64
+	}
+)
+
+// NewExporter - creates an exporter on top of the workflow API, call Run or Collect to fill it
+func NewExporter(api codefresh.IWorkflowAPI, opt *Options) *Exporter {
+	o := Options{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Interval <= 0 {
+		o.Interval = defaultInterval
+	}
+	if o.Window <= 0 {
+		o.Window = defaultWindow
+	}
+	if len(o.DurationBuckets) == 0 {
+		o.DurationBuckets = DefaultDurationBuckets
+	}
+	if len(o.QueueBuckets) == 0 {
+		o.QueueBuckets = DefaultQueueBuckets
+	}
+	o.DurationBuckets = sortedBounds(o.DurationBuckets)
+	o.QueueBuckets = sortedBounds(o.QueueBuckets)
+	return &Exporter{
+		api:       api,
+		opt:       o,
+		now:       time.Now,
+		durations: map[string]*histogram{},
+		queue:     map[string]*histogram{},
+		started:   map[string]bool{},
+		done:      map[string]bool{},
+	}
+}
+
+// Run - collects every Interval until ctx is done, failed collections keep the previous metrics
+func (e *Exporter) Run(ctx context.Context) error {
+	t := time.NewTicker(e.opt.Interval)
+	defer t.Stop()
+	for {
+		// errors are reported through codefresh_exporter_up and codefresh_exporter_errors_total
+		_ = e.Collect(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Collect - lists the builds of the window once and replaces the served metrics
+func (e *Exporter) Collect(ctx context.Context) error {
+	start := e.now()
+	s := &snapshot{
+		at:              start,
+		builds:          map[buildKey]int{},
+		finished:        map[string]int{},
+		succeeded:       map[string]int{},
+		pendingApproval: map[string]int{},
+	}
+	err := e.api.ForEachWithContext(ctx, &codefresh.WorkflowListOptions{
+		Limit:       listPageSize,
+		PipelineIDs: e.opt.Pipelines,
+		From:        start.Add(-e.opt.Window),
+	}, func(wf *codefresh.Workflow) error {
+		e.observe(s, wf)
+		return nil
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.duration = e.now().Sub(start)
+	if err != nil {
+		e.errors++
+		e.up = false
+		return err
+	}
+	e.up = true
+	e.last = s
+	e.observeHistograms(s.workflows)
+	return nil
+}
+
+func (e *Exporter) observe(s *snapshot, wf *codefresh.Workflow) {
+	pipeline := pipelineLabel(wf)
+	s.workflows = append(s.workflows, wf)
+	s.builds[buildKey{pipeline, wf.Status}]++
+	if wf.Status == codefresh.WorkflowStatusPendingApproval {
+		s.pendingApproval[pipeline]++
+	}
+	if codefresh.IsTerminalStatus(wf.Status) {
+		s.finished[pipeline]++
+		if wf.Status == codefresh.WorkflowStatusSuccess {
+			s.succeeded[pipeline]++
+		}
+	}
+}
+
+// observeHistograms adds the builds that started or finished since the previous collection,
+// the IDs are only kept while the builds are in the window, a build never comes back once out of it
+func (e *Exporter) observeHistograms(workflows []*codefresh.Workflow) {
+	started, done := map[string]bool{}, map[string]bool{}
+	for _, wf := range workflows {
+		pipeline := pipelineLabel(wf)
+		if !wf.Started.IsZero() && !wf.Created.IsZero() {
+			if !e.started[wf.ID] {
+				observeHistogram(e.queue, pipeline, e.opt.QueueBuckets, wf.Started.Sub(wf.Created))
+			}
+			started[wf.ID] = true
+		}
+		if codefresh.IsTerminalStatus(wf.Status) && !wf.Started.IsZero() && !wf.Finished.IsZero() {
+			if !e.done[wf.ID] {
+				observeHistogram(e.durations, pipeline, e.opt.DurationBuckets, wf.Finished.Sub(wf.Started))
+			}
+			done[wf.ID] = true
+		}
+	}
+	e.started, e.done = started, done
+}
+
+func pipelineLabel(wf *codefresh.Workflow) string {
+	if wf.PipelineName == "" {
+		return wf.PipelineID
+	}
+	return wf.PipelineName
+}
+
+func observeHistogram(hs map[string]*histogram, pipeline string, bounds []float64, d time.Duration) {
+	h, ok := hs[pipeline]
+	if !ok {
+		h = &histogram{bounds: bounds, counts: make([]int, len(bounds))}
+		hs[pipeline] = h
+	}
+	v := d.Seconds()
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func sortedBounds(bounds []float64) []float64 {
+	sorted := append([]float64{}, bounds...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// ServeHTTP - writes the metrics of the last successful collection, in OpenMetrics
+// when the scraper accepts it and in the Prometheus text format otherwise
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := FormatPrometheus
+	if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		format = FormatOpenMetrics
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	e.Write(w, format)
+}
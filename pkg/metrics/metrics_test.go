@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"github.com/stretchr/testify/assert"
+)
+
+const metricsTestBuilds = `{"workflows":{"total":4,"docs":[
+	{"id":"1","pipelineName":"proj/build","status":"success","created":"2020-05-20T10:00:00Z","started":"2020-05-20T10:00:10Z","finished":"2020-05-20T10:01:10Z"},
+	{"id":"2","pipelineName":"proj/build","status":"error","created":"2020-05-20T10:00:00Z","started":"2020-05-20T10:00:02Z","finished":"2020-05-20T10:10:02Z"},
+	{"id":"3","pipelineName":"proj/build","status":"pending-approval","created":"2020-05-20T10:00:00Z","started":"2020-05-20T10:00:01Z"},
+	{"id":"4","pipeline":"5f1d","status":"pending","created":"2020-05-20T10:00:00Z"}]}}`
+
+func TestExporter(t *testing.T) {
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, "/api/workflow", r.URL.Path)
+		assert.Equal(t, "2020-05-19T11:00:00Z", r.URL.Query().Get("startDate"))
+		w.Write([]byte(metricsTestBuilds))
+	}))
+	defer server.Close()
+
+	e := NewExporter(codefresh.New(&codefresh.ClientOptions{Host: server.URL}).Workflows(), &Options{
+		DurationBuckets: []float64{600, 60},
+	})
+	e.now = func() time.Time { return time.Date(2020, 5, 20, 11, 0, 0, 0, time.UTC) }
+	assert.NoError(t, e.Collect(context.Background()))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`# TYPE codefresh_builds gauge`,
+		`codefresh_builds{pipeline="5f1d",status="pending"} 1`,
+		`codefresh_builds{pipeline="proj/build",status="success"} 1`,
+		`codefresh_builds_pending_approval{pipeline="proj/build"} 1`,
+		`codefresh_build_success_ratio{pipeline="proj/build"} 0.5`,
+		`codefresh_build_duration_seconds_bucket{pipeline="proj/build",le="60"} 1`,
+		`codefresh_build_duration_seconds_bucket{pipeline="proj/build",le="600"} 2`,
+		`codefresh_build_duration_seconds_bucket{pipeline="proj/build",le="+Inf"} 2`,
+		`codefresh_build_duration_seconds_sum{pipeline="proj/build"} 660`,
+		`codefresh_build_queue_seconds_count{pipeline="proj/build"} 3`,
+		`codefresh_exporter_up 1`,
+		`# TYPE codefresh_exporter_errors_total counter`,
+		`codefresh_exporter_errors_total 0`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.NotContains(t, body, "# EOF")
+
+	failing = true
+	assert.Error(t, e.Collect(context.Background()))
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	e.ServeHTTP(rec, req)
+	body = rec.Body.String()
+	assert.Contains(t, body, "codefresh_exporter_up 0\n")
+	assert.Contains(t, body, "# TYPE codefresh_exporter_errors counter\ncodefresh_exporter_errors_total 1\n")
+	// the metrics of the last successful collection are still served
+	assert.Contains(t, body, `codefresh_builds{pipeline="proj/build",status="error"} 1`)
+	assert.True(t, strings.HasSuffix(body, "# EOF\n"))
+}
+
+func TestExporterCumulativeHistograms(t *testing.T) {
+	builds := metricsTestBuilds
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(builds))
+	}))
+	defer server.Close()
+
+	e := NewExporter(codefresh.New(&codefresh.ClientOptions{Host: server.URL}).Workflows(), &Options{
+		DurationBuckets: []float64{600, 60},
+	})
+	e.now = func() time.Time { return time.Date(2020, 5, 20, 11, 0, 0, 0, time.UTC) }
+	scrape := func() string {
+		assert.NoError(t, e.Collect(context.Background()))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		return rec.Body.String()
+	}
+
+	// the same builds collected twice are observed once
+	scrape()
+	body := scrape()
+	assert.Contains(t, body, `codefresh_build_duration_seconds_count{pipeline="proj/build"} 2`+"\n")
+	assert.Contains(t, body, `codefresh_build_queue_seconds_count{pipeline="proj/build"} 3`+"\n")
+
+	// build 1 left the window, build 3 was approved and finished
+	builds = `{"workflows":{"total":2,"docs":[
+		{"id":"2","pipelineName":"proj/build","status":"error","created":"2020-05-20T10:00:00Z","started":"2020-05-20T10:00:02Z","finished":"2020-05-20T10:10:02Z"},
+		{"id":"3","pipelineName":"proj/build","status":"success","created":"2020-05-20T10:00:00Z","started":"2020-05-20T10:00:01Z","finished":"2020-05-20T10:00:31Z"}]}}`
+	body = scrape()
+	for _, line := range []string{
+		`codefresh_build_duration_seconds_bucket{pipeline="proj/build",le="60"} 2`,
+		`codefresh_build_duration_seconds_count{pipeline="proj/build"} 3`,
+		`codefresh_build_duration_seconds_sum{pipeline="proj/build"} 690`,
+		`codefresh_build_queue_seconds_count{pipeline="proj/build"} 3`,
+		`codefresh_builds{pipeline="proj/build",status="success"} 1`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+}